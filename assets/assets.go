@@ -0,0 +1,29 @@
+// Package assets embeds the app's images in the binary, so they are found whichever directory it runs from.
+
+package assets
+
+import (
+	_ "embed"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//go:embed plane.png
+var Plane []byte
+
+var planePath string
+var writePlane sync.Once
+
+// PlanePath returns the path of a copy of the plane icon written to the temp directory, for things that need a
+// file rather than bytes. Returns "" if it can't be written
+func PlanePath() string {
+	writePlane.Do(func() {
+		path := filepath.Join(os.TempDir(), "planespotter-plane.png")
+		if err := os.WriteFile(path, Plane, 0644); err == nil {
+			planePath = path
+		}
+	})
+
+	return planePath
+}
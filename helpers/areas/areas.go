@@ -0,0 +1,277 @@
+// Package areas works out which spot areas a plane is in, whether a radius, polygon or corridor,
+// and merges several watched areas into as few API queries as possible without costing more OpenSky credits.
+
+package areas
+
+import (
+	"fmt"
+	"math"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/geo"
+	"planespotter/helpers/types"
+	"strconv"
+	"strings"
+)
+
+const Radius = "radius"
+const Polygon = "polygon"
+const Corridor = "corridor"
+
+// Shapes lists the spot area shapes that can be configured
+var Shapes = []string{Radius, Polygon, Corridor}
+
+const BaroAltitude = "barometric"
+const GeoAltitude = "geometric"
+
+// AltitudeSources lists the altitudes a Volume can be measured with
+var AltitudeSources = []string{BaroAltitude, GeoAltitude}
+
+const AnyRate = "any"
+const Climbing = "climbing"
+const Descending = "descending"
+const Level = "level"
+
+// VerticalRates lists the vertical rate conditions a Volume can have
+var VerticalRates = []string{AnyRate, Climbing, Descending, Level}
+
+// DefaultLevelRateMs is the vertical rate in m/s below which a plane counts as level when no threshold is set,
+// as reported rates are rarely exactly 0
+const DefaultLevelRateMs = 1.0
+
+// Group is a set of areas that are fetched with a single API query covering Box
+type Group struct {
+	Box   types.BoundingBox
+	Areas []types.Area
+}
+
+// Box takes an Area and returns the smallest BoundingBox around its shape
+// For a radius that is SpotDistanceKm either side of its position, for a polygon the box around its points,
+// and for a corridor the box around its line widened by CorridorWidthKm
+func Box(area types.Area) types.BoundingBox {
+	switch {
+	case IsShaped(area) && area.Shape.Type == Polygon:
+		return pointsBox(area.Shape.Points, 0)
+	case IsShaped(area) && area.Shape.Type == Corridor:
+		return pointsBox(area.Shape.Points, area.Shape.CorridorWidthKm)
+	}
+
+	latOffset := formatters.KmToLatitude(area.SpotDistanceKm)
+	lonOffset := formatters.KmToLongitude(float64(area.SpotDistanceKm), area.Position.Latitude)
+
+	return worldBox(types.BoundingBox{
+		LaMin: area.Position.Latitude - latOffset,
+		LaMax: area.Position.Latitude + latOffset,
+		LoMin: area.Position.Longitude - lonOffset,
+		LoMax: area.Position.Longitude + lonOffset,
+	})
+}
+
+// Contains takes an Area and a latitude and longitude, and returns true if the position is inside the area's shape
+func Contains(area types.Area, lat, lon float64) bool {
+	pos := types.Position{Latitude: lat, Longitude: lon}
+	switch {
+	case IsShaped(area) && area.Shape.Type == Polygon:
+		return geo.InPolygon(pos, area.Shape.Points)
+	case IsShaped(area) && area.Shape.Type == Corridor:
+		return geo.DistanceToLineKm(pos, area.Shape.Points) <= area.Shape.CorridorWidthKm
+	}
+
+	box := Box(area)
+	return lat >= box.LaMin && lat <= box.LaMax && lon >= box.LoMin && lon <= box.LoMax
+}
+
+// InVolume takes a Volume and a plane's StateVector, and returns true if the plane is within the volume's altitude
+// band and meets its vertical rate condition. A plane without the altitude or vertical rate needed is outside it
+func InVolume(volume types.Volume, state types.StateVector) bool {
+	if volume.MinAltitudeM > 0 || volume.MaxAltitudeM > 0 {
+		altitude, hasAltitude := state.Baro_Altitude, state.Has_Baro_Altitude
+		if volume.AltitudeSource == GeoAltitude {
+			altitude, hasAltitude = state.Geo_Altitude, state.Has_Geo_Altitude
+		}
+
+		if !hasAltitude || altitude < volume.MinAltitudeM || (volume.MaxAltitudeM > 0 && altitude > volume.MaxAltitudeM) {
+			return false
+		}
+	}
+
+	switch volume.VerticalRate {
+	case Climbing:
+		return state.Has_Vertical_Rate && state.Vertical_Rate >= volume.MinVerticalRateMs
+	case Descending:
+		return state.Has_Vertical_Rate && state.Vertical_Rate <= -volume.MinVerticalRateMs
+	case Level:
+		threshold := volume.MinVerticalRateMs
+		if threshold == 0 {
+			threshold = DefaultLevelRateMs
+		}
+		return state.Has_Vertical_Rate && math.Abs(state.Vertical_Rate) < threshold
+	default:
+		return true
+	}
+}
+
+// IsShaped takes an Area and returns true if it has a usable polygon or corridor shape rather than a radius
+// A polygon needs at least 3 points and a corridor at least 1, otherwise the radius is used
+func IsShaped(area types.Area) bool {
+	switch area.Shape.Type {
+	case Polygon:
+		return len(area.Shape.Points) >= 3
+	case Corridor:
+		return len(area.Shape.Points) >= 1
+	default:
+		return false
+	}
+}
+
+// ParsePoints takes text with one "latitude, longitude" pair per line, and returns the Positions
+// Blank lines are skipped. Returns an error naming the line if a pair can't be read
+func ParsePoints(text string) ([]types.Position, error) {
+	var points []types.Position
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Split(line, ",")
+		if len(parts) != 2 {
+			return points, fmt.Errorf("line %v: expected latitude, longitude", i+1)
+		}
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		lon, lonErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if latErr != nil || lonErr != nil {
+			return points, fmt.Errorf("line %v: expected latitude, longitude in decimal degrees", i+1)
+		}
+		points = append(points, types.Position{Latitude: lat, Longitude: lon})
+	}
+
+	return points, nil
+}
+
+// FormatPoints takes a slice of Position and returns them as text with one "latitude, longitude" pair per line
+func FormatPoints(points []types.Position) string {
+	var lines []string
+	for _, p := range points {
+		lines = append(lines, fmt.Sprintf("%v, %v", p.Latitude, p.Longitude))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GlobalCredits is what a query without a box costs, such as one for watchlisted aircraft anywhere in the world
+const GlobalCredits = 4
+
+// Credits takes a BoundingBox and returns the OpenSky API credits a query for it costs, which go up with its area
+// in square degrees: up to 25 costs 1, up to 100 costs 2, up to 400 costs 3 and anything larger costs 4
+func Credits(box types.BoundingBox) int {
+	squareDegrees := (box.LaMax - box.LaMin) * (box.LoMax - box.LoMin)
+	switch {
+	case squareDegrees <= 25:
+		return 1
+	case squareDegrees <= 100:
+		return 2
+	case squareDegrees <= 400:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Merge takes a slice of Area and returns them in as few Groups as possible
+// Two groups are merged when one query for both costs fewer credits than querying each, or when they overlap and
+// it costs no more, as overlapping queries would download the same planes twice
+func Merge(areas []types.Area) []Group {
+	var groups []Group
+	for _, a := range areas {
+		groups = append(groups, Group{Box: Box(a), Areas: []types.Area{a}})
+	}
+
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(groups) && !merged; i++ {
+			for j := i + 1; j < len(groups) && !merged; j++ {
+				union := unionBox(groups[i].Box, groups[j].Box)
+				separate := Credits(groups[i].Box) + Credits(groups[j].Box)
+				if Credits(union) < separate || (overlaps(groups[i].Box, groups[j].Box) && Credits(union) <= separate) {
+					groups[i] = Group{Box: union, Areas: append(groups[i].Areas, groups[j].Areas...)}
+					groups = append(groups[:j], groups[j+1:]...)
+					merged = true
+				}
+			}
+		}
+	}
+
+	return groups
+}
+
+// Tag takes the planes returned for a Group's query and the Group's areas, and returns the planes that are inside
+// at least one area's shape and volume, with Area set to the names of the areas they are in
+// When a query is for a single radius area every plane returned is inside its shape, so only its volume is checked
+func Tag(planeInfos []types.PlaneInfo, areas []types.Area) []types.PlaneInfo {
+	var tagged []types.PlaneInfo
+	for _, p := range planeInfos {
+		var names []string
+		for _, a := range areas {
+			inShape := (len(areas) == 1 && !IsShaped(a)) || (p.State.Has_Position && Contains(a, p.State.Latitude, p.State.Longitude))
+			if inShape && InVolume(a.Volume, p.State) {
+				names = append(names, a.Name)
+			}
+		}
+
+		if len(names) > 0 {
+			p.Area = strings.Join(names, ", ")
+			tagged = append(tagged, p)
+		}
+	}
+
+	return tagged
+}
+
+// pointsBox takes a slice of Position and a margin in km, and returns the BoundingBox around the points
+// with the margin added on every side
+func pointsBox(points []types.Position, marginKm float64) types.BoundingBox {
+	box := types.BoundingBox{LaMin: 90, LaMax: -90, LoMin: 180, LoMax: -180}
+	for _, p := range points {
+		box = unionBox(box, types.BoundingBox{LaMin: p.Latitude, LaMax: p.Latitude, LoMin: p.Longitude, LoMax: p.Longitude})
+	}
+
+	latOffset := marginKm / 111.1
+	// Use the latitude furthest from the equator, where a km is the most degrees of longitude
+	lonOffset := formatters.KmToLongitude(marginKm, math.Max(math.Abs(box.LaMin), math.Abs(box.LaMax)))
+
+	box.LaMin -= latOffset
+	box.LaMax += latOffset
+	box.LoMin -= lonOffset
+	box.LoMax += lonOffset
+	return worldBox(box)
+}
+
+// worldBox takes a BoundingBox and returns it cut off at the poles and at 180 degrees east and west, as a box near
+// a pole or the antimeridian can reach past them. A box at least 360 degrees wide covers every longitude
+func worldBox(box types.BoundingBox) types.BoundingBox {
+	if box.LoMax-box.LoMin >= 360 {
+		box.LoMin, box.LoMax = -180, 180
+	}
+
+	return types.BoundingBox{
+		LaMin: math.Max(box.LaMin, -90),
+		LaMax: math.Min(box.LaMax, 90),
+		LoMin: math.Max(box.LoMin, -180),
+		LoMax: math.Min(box.LoMax, 180),
+	}
+}
+
+// unionBox takes two BoundingBoxes and returns the smallest BoundingBox containing both
+func unionBox(a, b types.BoundingBox) types.BoundingBox {
+	return types.BoundingBox{
+		LaMin: math.Min(a.LaMin, b.LaMin),
+		LaMax: math.Max(a.LaMax, b.LaMax),
+		LoMin: math.Min(a.LoMin, b.LoMin),
+		LoMax: math.Max(a.LoMax, b.LoMax),
+	}
+}
+
+// overlaps takes two BoundingBoxes and returns true if they overlap or touch
+func overlaps(a, b types.BoundingBox) bool {
+	return a.LaMin <= b.LaMax && b.LaMin <= a.LaMax && a.LoMin <= b.LoMax && b.LoMin <= a.LoMax
+}
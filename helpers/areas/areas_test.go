@@ -0,0 +1,218 @@
+package areas
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var home = types.Area{Name: "Home", Position: types.Position{Latitude: 51.5, Longitude: -0.1}, SpotDistanceKm: 20}
+var airport = types.Area{Name: "Airport", Position: types.Position{Latitude: 51.47, Longitude: -0.45}, SpotDistanceKm: 10}
+var faraway = types.Area{Name: "Faraway", Position: types.Position{Latitude: 40.6, Longitude: -73.8}, SpotDistanceKm: 20}
+
+func TestBox(t *testing.T) {
+	box := Box(types.Area{Position: types.Position{Latitude: 0, Longitude: 0}, SpotDistanceKm: 111})
+
+	assert.InDelta(t, -0.999, box.LaMin, 0.001)
+	assert.InDelta(t, 0.999, box.LaMax, 0.001)
+	assert.InDelta(t, -0.997, box.LoMin, 0.001)
+	assert.InDelta(t, 0.997, box.LoMax, 0.001)
+
+	// At the pole every longitude is within reach, and the box stops at the pole
+	box = Box(types.Area{Position: types.Position{Latitude: 90, Longitude: 10}, SpotDistanceKm: 20})
+	assert.Equal(t, types.BoundingBox{LaMin: 90 - 20/111.1, LaMax: 90, LoMin: -180, LoMax: 180}, box)
+}
+
+func TestContains(t *testing.T) {
+	tests := []struct {
+		lat      float64
+		lon      float64
+		expected bool
+	}{
+		{lat: 51.5, lon: -0.1, expected: true},
+		{lat: 51.6, lon: -0.1, expected: true},
+		{lat: 52.0, lon: -0.1, expected: false},
+		{lat: 51.5, lon: 1.0, expected: false},
+	}
+
+	for _, test := range tests {
+		res := Contains(home, test.lat, test.lon)
+		assert.Equal(t, test.expected, res, "Contains(%+v, %v, %v)", home, test.lat, test.lon)
+	}
+}
+
+func TestCredits(t *testing.T) {
+	tests := []struct {
+		box      types.BoundingBox
+		expected int
+	}{
+		{box: types.BoundingBox{LaMin: 0, LaMax: 1, LoMin: 0, LoMax: 1}, expected: 1},
+		{box: types.BoundingBox{LaMin: 0, LaMax: 5, LoMin: 0, LoMax: 5}, expected: 1},
+		{box: types.BoundingBox{LaMin: 0, LaMax: 10, LoMin: 0, LoMax: 10}, expected: 2},
+		{box: types.BoundingBox{LaMin: 0, LaMax: 20, LoMin: 0, LoMax: 20}, expected: 3},
+		{box: types.BoundingBox{LaMin: -90, LaMax: 90, LoMin: -180, LoMax: 180}, expected: 4},
+	}
+
+	for _, test := range tests {
+		res := Credits(test.box)
+		assert.Equal(t, test.expected, res, "Credits(%+v)", test.box)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// Home and the airport are close enough to share one 1 credit query, but New York is not
+	groups := Merge([]types.Area{home, faraway, airport})
+
+	assert.Len(t, groups, 2)
+	assert.Equal(t, []types.Area{home, airport}, groups[0].Areas)
+	assert.Equal(t, []types.Area{faraway}, groups[1].Areas)
+	assert.Equal(t, 1, Credits(groups[0].Box))
+	assert.InDelta(t, Box(airport).LoMin, groups[0].Box.LoMin, 0.0001)
+	assert.InDelta(t, Box(home).LoMax, groups[0].Box.LoMax, 0.0001)
+	assert.Equal(t, Box(faraway), groups[1].Box)
+
+	assert.Len(t, Merge([]types.Area{home}), 1)
+	assert.Empty(t, Merge(nil))
+}
+
+func TestMergeLargeAreas(t *testing.T) {
+	// Two 2 credit areas near each other cost 3 credits together, so are merged
+	west := types.Area{Name: "West", Position: types.Position{Latitude: 0, Longitude: -4}, SpotDistanceKm: 400}
+	east := types.Area{Name: "East", Position: types.Position{Latitude: 0, Longitude: 4}, SpotDistanceKm: 400}
+	assert.Len(t, Merge([]types.Area{west, east}), 1)
+
+	// But two far apart would cost 4, so are kept separate
+	east.Position.Longitude = 100
+	assert.Len(t, Merge([]types.Area{west, east}), 2)
+}
+
+func TestTag(t *testing.T) {
+	atHome := types.PlaneInfo{Callsign: "HOME1", State: types.StateVector{Has_Position: true, Latitude: 51.5, Longitude: -0.1}}
+	atAirport := types.PlaneInfo{Callsign: "AIRPORT1", State: types.StateVector{Has_Position: true, Latitude: 51.47, Longitude: -0.45}}
+	inBoth := types.PlaneInfo{Callsign: "BOTH1", State: types.StateVector{Has_Position: true, Latitude: 51.47, Longitude: -0.35}}
+	between := types.PlaneInfo{Callsign: "NEITHER1", State: types.StateVector{Has_Position: true, Latitude: 51.65, Longitude: -0.6}}
+	noPosition := types.PlaneInfo{Callsign: "NOPOS1"}
+
+	res := Tag([]types.PlaneInfo{atHome, atAirport, inBoth, between, noPosition}, []types.Area{home, airport})
+	assert.Len(t, res, 3)
+	assert.Equal(t, "Home", res[0].Area)
+	assert.Equal(t, "Airport", res[1].Area)
+	assert.Equal(t, "Home, Airport", res[2].Area)
+
+	res = Tag([]types.PlaneInfo{between, noPosition}, []types.Area{home})
+	assert.Len(t, res, 2)
+	assert.Equal(t, "Home", res[1].Area)
+}
+
+var valley = types.Area{Name: "Valley", Shape: types.SpotShape{Type: Polygon, Points: []types.Position{
+	{Latitude: 51.0, Longitude: -1.0},
+	{Latitude: 51.2, Longitude: -1.0},
+	{Latitude: 51.2, Longitude: -0.5},
+}}}
+
+var approach = types.Area{Name: "Approach", Shape: types.SpotShape{Type: Corridor, CorridorWidthKm: 2, Points: []types.Position{
+	{Latitude: 51.4650, Longitude: -0.4340},
+	{Latitude: 51.4650, Longitude: -0.1},
+}}}
+
+func TestShapeBox(t *testing.T) {
+	assert.Equal(t, types.BoundingBox{LaMin: 51.0, LaMax: 51.2, LoMin: -1.0, LoMax: -0.5}, Box(valley))
+
+	box := Box(approach)
+	assert.InDelta(t, 51.4470, box.LaMin, 0.0001)
+	assert.InDelta(t, 51.4830, box.LaMax, 0.0001)
+	assert.InDelta(t, -0.4629, box.LoMin, 0.0001)
+	assert.InDelta(t, -0.0711, box.LoMax, 0.0001)
+
+	// Without enough points the radius is used instead
+	broken := types.Area{Position: types.Position{Latitude: 0, Longitude: 0}, SpotDistanceKm: 111, Shape: types.SpotShape{Type: Polygon, Points: valley.Shape.Points[:2]}}
+	assert.False(t, IsShaped(broken))
+	assert.InDelta(t, 0.999, Box(broken).LaMax, 0.001)
+}
+
+func TestShapeContains(t *testing.T) {
+	tests := []struct {
+		area     types.Area
+		lat      float64
+		lon      float64
+		expected bool
+	}{
+		{area: valley, lat: 51.15, lon: -0.9, expected: true},
+		{area: valley, lat: 51.05, lon: -0.6, expected: false},
+		{area: approach, lat: 51.4650, lon: -0.2, expected: true},
+		{area: approach, lat: 51.4800, lon: -0.2, expected: true},
+		{area: approach, lat: 51.4900, lon: -0.2, expected: false},
+		{area: approach, lat: 51.4650, lon: -0.05, expected: false},
+	}
+
+	for _, test := range tests {
+		res := Contains(test.area, test.lat, test.lon)
+		assert.Equal(t, test.expected, res, "Contains(%v, %v, %v)", test.area.Name, test.lat, test.lon)
+	}
+}
+
+func TestTagShape(t *testing.T) {
+	inside := types.PlaneInfo{Callsign: "IN1", State: types.StateVector{Has_Position: true, Latitude: 51.15, Longitude: -0.9}}
+	inBoxOnly := types.PlaneInfo{Callsign: "OUT1", State: types.StateVector{Has_Position: true, Latitude: 51.05, Longitude: -0.6}}
+
+	res := Tag([]types.PlaneInfo{inside, inBoxOnly}, []types.Area{valley})
+	assert.Len(t, res, 1)
+	assert.Equal(t, "IN1", res[0].Callsign)
+}
+
+func TestParsePoints(t *testing.T) {
+	res, err := ParsePoints("51.0, -1.0\n\n 51.2,-0.5 \n")
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Position{{Latitude: 51.0, Longitude: -1.0}, {Latitude: 51.2, Longitude: -0.5}}, res)
+	assert.Equal(t, "51, -1\n51.2, -0.5", FormatPoints(res))
+
+	_, err = ParsePoints("51.0, -1.0\n51.2")
+	assert.EqualError(t, err, "line 2: expected latitude, longitude")
+
+	_, err = ParsePoints("north, west")
+	assert.Error(t, err)
+}
+
+func TestInVolume(t *testing.T) {
+	low := types.StateVector{Has_Baro_Altitude: true, Baro_Altitude: 600, Has_Geo_Altitude: true, Geo_Altitude: 1200, Has_Vertical_Rate: true, Vertical_Rate: -4}
+	noAltitude := types.StateVector{Has_Vertical_Rate: true}
+
+	tests := []struct {
+		volume   types.Volume
+		state    types.StateVector
+		expected bool
+	}{
+		{volume: types.Volume{}, state: noAltitude, expected: true},
+		{volume: types.Volume{MaxAltitudeM: 1000}, state: low, expected: true},
+		{volume: types.Volume{MaxAltitudeM: 1000, AltitudeSource: GeoAltitude}, state: low, expected: false},
+		{volume: types.Volume{MinAltitudeM: 1000}, state: low, expected: false},
+		{volume: types.Volume{MinAltitudeM: 1000, AltitudeSource: GeoAltitude}, state: low, expected: true},
+		{volume: types.Volume{MaxAltitudeM: 1000}, state: noAltitude, expected: false},
+		{volume: types.Volume{VerticalRate: Descending, MinVerticalRateMs: 2}, state: low, expected: true},
+		{volume: types.Volume{VerticalRate: Descending, MinVerticalRateMs: 5}, state: low, expected: false},
+		{volume: types.Volume{VerticalRate: Climbing, MinVerticalRateMs: 2}, state: low, expected: false},
+		{volume: types.Volume{VerticalRate: Level, MinVerticalRateMs: 5}, state: low, expected: true},
+		{volume: types.Volume{VerticalRate: Level, MinVerticalRateMs: 1}, state: low, expected: false},
+		{volume: types.Volume{VerticalRate: Level}, state: low, expected: false},
+		{volume: types.Volume{VerticalRate: Level}, state: types.StateVector{Has_Vertical_Rate: true, Vertical_Rate: 0.3}, expected: true},
+		{volume: types.Volume{VerticalRate: Climbing}, state: types.StateVector{}, expected: false},
+		{volume: types.Volume{VerticalRate: AnyRate}, state: types.StateVector{}, expected: true},
+	}
+
+	for _, test := range tests {
+		res := InVolume(test.volume, test.state)
+		assert.Equal(t, test.expected, res, "InVolume(%+v, %+v)", test.volume, test.state)
+	}
+}
+
+func TestTagVolume(t *testing.T) {
+	lowHome := home
+	lowHome.Volume = types.Volume{MaxAltitudeM: 1000}
+	low := types.PlaneInfo{Callsign: "LOW1", State: types.StateVector{Has_Baro_Altitude: true, Baro_Altitude: 600}}
+	high := types.PlaneInfo{Callsign: "HIGH1", State: types.StateVector{Has_Baro_Altitude: true, Baro_Altitude: 11000}}
+
+	res := Tag([]types.PlaneInfo{low, high}, []types.Area{lowHome})
+	assert.Len(t, res, 1)
+	assert.Equal(t, "LOW1", res[0].Callsign)
+}
@@ -0,0 +1,178 @@
+// Package auth adds OpenSky credentials to API requests, as an OAuth2 bearer token fetched with client credentials
+// and cached until shortly before it expires, or as basic auth for accounts without an API client.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"planespotter/helpers/fetch"
+	"planespotter/helpers/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenUrl is OpenSky's token endpoint, used when the config doesn't give one
+const DefaultTokenUrl = "https://auth.opensky-network.org/auth/realms/opensky-network/protocol/openid-connect/token"
+
+// RefreshMargin is how long before a token expires that a new one is fetched, so a request never goes out
+// with a token that expires on the way
+const RefreshMargin = 30 * time.Second
+
+// DefaultExpiry is how long a token is kept when the token endpoint doesn't say when it expires
+const DefaultExpiry = 5 * time.Minute
+
+// Authorizer adds credentials to API requests
+// Reset forgets any cached token, for when the API rejects it
+type Authorizer interface {
+	Authorize(req *http.Request) error
+	Reset()
+}
+
+// None sends requests without credentials, as an anonymous user
+type None struct{}
+
+// Authorize leaves the request as it is
+func (None) Authorize(req *http.Request) error { return nil }
+
+// Reset does nothing, as there is nothing cached
+func (None) Reset() {}
+
+// Basic sends a username and password with every request as basic auth
+type Basic struct {
+	Username string
+	Password string
+}
+
+// Authorize sets the request's Authorization header to the username and password
+func (b Basic) Authorize(req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
+
+// Reset does nothing, as there is nothing cached
+func (Basic) Reset() {}
+
+// ClientCredentials fetches bearer tokens from TokenUrl with an OAuth2 client id and secret, and caches each one
+// until RefreshMargin before it expires. It is safe to use from more than one goroutine
+type ClientCredentials struct {
+	ClientId     string
+	ClientSecret string
+	TokenUrl     string
+	Client       *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	now     func() time.Time
+}
+
+// tokenResponse is the part of an OAuth2 token response that is used
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// NewClientCredentials takes a client id, client secret and token url, and returns ClientCredentials for them
+// A blank token url uses DefaultTokenUrl
+func NewClientCredentials(clientId, clientSecret, tokenUrl string) *ClientCredentials {
+	if tokenUrl == "" {
+		tokenUrl = DefaultTokenUrl
+	}
+
+	return &ClientCredentials{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		TokenUrl:     tokenUrl,
+		Client:       fetch.NewClient(10 * time.Second),
+		now:          time.Now,
+	}
+}
+
+// Authorize sets the request's Authorization header to a bearer token, fetching a new one if there isn't one
+// cached or it is about to expire. Returns an error if a token can't be fetched
+func (c *ClientCredentials) Authorize(req *http.Request) error {
+	token, err := c.Token()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Reset forgets the cached token, so the next request fetches a new one
+func (c *ClientCredentials) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// Token returns the cached bearer token, or fetches a new one if there isn't one or it expires within RefreshMargin
+// Returns a fetch.Error if the token endpoint can't be reached or doesn't return a token
+func (c *ClientCredentials) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Before(c.expires.Add(-RefreshMargin)) {
+		return c.token, nil
+	}
+
+	token, expiresIn, err := c.fetch()
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expires = c.now().Add(expiresIn)
+	return c.token, nil
+}
+
+// fetch requests a new token from the token endpoint, and returns it and how long it lasts
+func (c *ClientCredentials) fetch() (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.ClientId},
+		"client_secret": {c.ClientSecret},
+	}
+	resp, err := c.Client.Post(c.TokenUrl, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err := fetch.Classify(resp, err); err != nil {
+		return "", 0, fmt.Errorf("error getting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var t tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", 0, fmt.Errorf("error reading token: %w", &fetch.Error{Kind: fetch.Parse, Err: err})
+	}
+	if t.AccessToken == "" {
+		return "", 0, fmt.Errorf("error reading token: %w", &fetch.Error{Kind: fetch.Parse, Err: errors.New("no access_token in the response")})
+	}
+	if t.TokenType != "" && !strings.EqualFold(t.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("error reading token: %w", &fetch.Error{Kind: fetch.Auth, Err: fmt.Errorf("expected a bearer token, got %q", t.TokenType)})
+	}
+
+	expiresIn := DefaultExpiry
+	if t.ExpiresIn > 0 {
+		expiresIn = time.Duration(t.ExpiresIn) * time.Second
+	}
+	return t.AccessToken, expiresIn, nil
+}
+
+// New takes the ApiAuth from the config and returns its Authorizer: client credentials if there is a client id,
+// otherwise basic auth if there is a username, otherwise None
+func New(settings types.ApiAuth) Authorizer {
+	switch {
+	case settings.ClientId != "":
+		return NewClientCredentials(settings.ClientId, settings.ClientSecret, settings.TokenUrl)
+	case settings.Username != "":
+		return Basic{Username: settings.Username, Password: settings.Password}
+	default:
+		return None{}
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"planespotter/helpers/fetch"
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTokenServer returns a token server that checks the client credentials and hands out numbered tokens
+// lasting expiresIn seconds, and a count of the tokens it has handed out
+func fakeTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int) {
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		if r.PostForm.Get("client_id") != "spotter-api-client" || r.PostForm.Get("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		issued++
+		fmt.Fprintf(w, `{"access_token":"token-%v","token_type":"Bearer","expires_in":%v}`, issued, expiresIn)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &issued
+}
+
+func TestClientCredentials(t *testing.T) {
+	server, issued := fakeTokenServer(t, 1800)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c := NewClientCredentials("spotter-api-client", "s3cret", server.URL)
+	c.now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "https://opensky-network.org/api/states/all", nil)
+	assert.NoError(t, c.Authorize(req))
+	assert.Equal(t, "Bearer token-1", req.Header.Get("Authorization"))
+
+	// Cached until RefreshMargin before it expires
+	now = now.Add(29 * time.Minute)
+	token, err := c.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, 1, *issued)
+
+	now = now.Add(31 * time.Second)
+	token, err = c.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// A rejected token is fetched again
+	c.Reset()
+	token, _ = c.Token()
+	assert.Equal(t, "token-3", token)
+}
+
+func TestClientCredentialsErrors(t *testing.T) {
+	server, _ := fakeTokenServer(t, 1800)
+
+	_, err := NewClientCredentials("spotter-api-client", "wrong", server.URL).Token()
+	assert.EqualError(t, err, "error getting token: auth error: status 401: Unauthorized")
+	assert.Equal(t, fetch.Auth, fetch.KindOf(err))
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token_type":"Bearer"}`))
+	}))
+	defer empty.Close()
+	_, err = NewClientCredentials("spotter-api-client", "s3cret", empty.URL).Token()
+	assert.EqualError(t, err, "error reading token: parse error: no access_token in the response")
+
+	// The secret never ends up in an error
+	_, err = NewClientCredentials("spotter-api-client", "s3cret", "http://127.0.0.1:1").Token()
+	assert.Equal(t, fetch.Network, fetch.KindOf(err))
+	assert.NotContains(t, err.Error(), "s3cret")
+}
+
+func TestClientCredentialsDefaultExpiry(t *testing.T) {
+	server, issued := fakeTokenServer(t, 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	c := NewClientCredentials("spotter-api-client", "s3cret", server.URL)
+	c.now = func() time.Time { return now }
+
+	c.Token()
+	now = now.Add(DefaultExpiry - RefreshMargin - time.Second)
+	c.Token()
+	assert.Equal(t, 1, *issued)
+}
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, None{}, New(types.ApiAuth{}))
+	assert.Equal(t, Basic{Username: "spotter", Password: "pass"}, New(types.ApiAuth{Username: "spotter", Password: "pass"}))
+
+	c, ok := New(types.ApiAuth{Username: "spotter", ClientId: "spotter-api-client", ClientSecret: "s3cret"}).(*ClientCredentials)
+	if assert.True(t, ok) {
+		assert.Equal(t, DefaultTokenUrl, c.TokenUrl)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://opensky-network.org/api/states/all", nil)
+	Basic{Username: "spotter", Password: "pass"}.Authorize(req)
+	username, password, _ := req.BasicAuth()
+	assert.Equal(t, "spotter", username)
+	assert.Equal(t, "pass", password)
+}
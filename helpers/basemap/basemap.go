@@ -0,0 +1,204 @@
+// Package basemap loads offline vector map data (coastlines, borders, airports and runways)
+// from local GeoJSON files and projects it around the observer for drawing under the aircraft.
+
+package basemap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+)
+
+// LayerNames lists the layers planespotter looks for in the basemap directory, in the order they are drawn
+var LayerNames = []string{"coastlines", "borders", "runways", "airports"}
+
+// Feature is a single named map feature made up of any number of lines and points
+type Feature struct {
+	Name   string
+	Lines  [][]types.Position
+	Points []types.Position
+}
+
+// Layer is a named collection of features loaded from one GeoJSON file
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+type geoJson struct {
+	Type       string                 `json:"type"`
+	Features   []geoJson              `json:"features"`
+	Geometry   *geoJson               `json:"geometry"`
+	Geometries []geoJson              `json:"geometries"`
+	Properties map[string]interface{} `json:"properties"`
+	Coords     json.RawMessage        `json:"coordinates"`
+}
+
+// LoadLayers takes a directory and loads each of the known LayerNames from <name>.geojson within it
+// Layers without a file are skipped, so a directory with only coastlines is fine
+// Returns an error if a file exists but cannot be read or parsed
+func LoadLayers(dir string) ([]Layer, error) {
+	var layers []Layer
+	for _, name := range LayerNames {
+		path := filepath.Join(dir, name+".geojson")
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return layers, err
+		}
+
+		layer, err := ParseGeoJson(name, data)
+		if err != nil {
+			return layers, fmt.Errorf("error parsing %v: %w", path, err)
+		}
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+// ParseGeoJson takes a layer name and GeoJSON data (a FeatureCollection, Feature or bare geometry)
+// Returns a Layer with every Point, LineString and Polygon flattened into points and lines
+func ParseGeoJson(name string, data []byte) (Layer, error) {
+	layer := Layer{Name: name}
+
+	var g geoJson
+	if err := json.Unmarshal(data, &g); err != nil {
+		return layer, err
+	}
+
+	switch g.Type {
+	case "FeatureCollection":
+		for _, f := range g.Features {
+			feature, err := parseFeature(f)
+			if err != nil {
+				return layer, err
+			}
+			layer.Features = append(layer.Features, feature)
+		}
+	case "Feature":
+		feature, err := parseFeature(g)
+		if err != nil {
+			return layer, err
+		}
+		layer.Features = append(layer.Features, feature)
+	default:
+		var feature Feature
+		if err := addGeometry(&feature, g); err != nil {
+			return layer, err
+		}
+		layer.Features = append(layer.Features, feature)
+	}
+
+	return layer, nil
+}
+
+// parseFeature takes a GeoJSON Feature and returns it as a Feature, named from its "name", "icao" or "ref" property
+func parseFeature(g geoJson) (Feature, error) {
+	var feature Feature
+	for _, key := range []string{"name", "icao", "ref"} {
+		if name, ok := g.Properties[key].(string); ok && name != "" {
+			feature.Name = name
+			break
+		}
+	}
+
+	if g.Geometry == nil {
+		return feature, nil
+	}
+
+	err := addGeometry(&feature, *g.Geometry)
+	return feature, err
+}
+
+// addGeometry takes a Feature and a GeoJSON geometry, and appends the geometry's points and lines to the Feature
+// Polygon rings are treated as closed lines as the basemap is only ever drawn as outlines
+func addGeometry(feature *Feature, g geoJson) error {
+	var err error
+	switch g.Type {
+	case "Point":
+		var c []float64
+		if err = json.Unmarshal(g.Coords, &c); err == nil {
+			var p types.Position
+			p, err = toPosition(c)
+			feature.Points = append(feature.Points, p)
+		}
+	case "MultiPoint":
+		var c [][]float64
+		if err = json.Unmarshal(g.Coords, &c); err == nil {
+			var line []types.Position
+			line, err = toLine(c)
+			feature.Points = append(feature.Points, line...)
+		}
+	case "LineString":
+		var c [][]float64
+		if err = json.Unmarshal(g.Coords, &c); err == nil {
+			err = appendLines(feature, [][][]float64{c})
+		}
+	case "MultiLineString", "Polygon":
+		var c [][][]float64
+		if err = json.Unmarshal(g.Coords, &c); err == nil {
+			err = appendLines(feature, c)
+		}
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err = json.Unmarshal(g.Coords, &c); err == nil {
+			for _, polygon := range c {
+				if err = appendLines(feature, polygon); err != nil {
+					break
+				}
+			}
+		}
+	case "GeometryCollection":
+		for _, child := range g.Geometries {
+			if err = addGeometry(feature, child); err != nil {
+				break
+			}
+		}
+	default:
+		err = fmt.Errorf("unsupported geometry type %q", g.Type)
+	}
+
+	return err
+}
+
+// appendLines takes a Feature and a slice of GeoJSON coordinate lines, and appends them to the Feature's lines
+func appendLines(feature *Feature, lines [][][]float64) error {
+	for _, c := range lines {
+		line, err := toLine(c)
+		if err != nil {
+			return err
+		}
+		feature.Lines = append(feature.Lines, line)
+	}
+
+	return nil
+}
+
+// toLine takes a slice of GeoJSON coordinates and returns them as a slice of Position
+func toLine(coords [][]float64) ([]types.Position, error) {
+	var line []types.Position
+	for _, c := range coords {
+		p, err := toPosition(c)
+		if err != nil {
+			return line, err
+		}
+		line = append(line, p)
+	}
+
+	return line, nil
+}
+
+// toPosition takes a GeoJSON coordinate, which is [longitude, latitude(, elevation)], and returns it as a Position
+func toPosition(coord []float64) (types.Position, error) {
+	if len(coord) < 2 {
+		return types.Position{}, fmt.Errorf("coordinate %v needs at least a longitude and latitude", coord)
+	}
+
+	return types.Position{Longitude: coord[0], Latitude: coord[1]}, nil
+}
@@ -0,0 +1,146 @@
+package basemap
+
+import (
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGeoJson = `{
+	"type": "FeatureCollection",
+	"features": [
+		{
+			"type": "Feature",
+			"properties": {"name": "EGLL", "icao": "EGLL"},
+			"geometry": {"type": "Point", "coordinates": [-0.4614, 51.4775]}
+		},
+		{
+			"type": "Feature",
+			"properties": {"ref": "09L/27R"},
+			"geometry": {"type": "LineString", "coordinates": [[-0.4850, 51.4775], [-0.4333, 51.4776]]}
+		},
+		{
+			"type": "Feature",
+			"properties": {},
+			"geometry": {"type": "MultiPolygon", "coordinates": [[[[0, 0], [1, 0], [1, 1], [0, 0]]], [[[2, 2], [3, 2], [3, 3], [2, 2]]]]}
+		}
+	]
+}`
+
+func TestParseGeoJson(t *testing.T) {
+	layer, err := ParseGeoJson("airports", []byte(testGeoJson))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "airports", layer.Name)
+	assert.Len(t, layer.Features, 3)
+
+	assert.Equal(t, "EGLL", layer.Features[0].Name)
+	assert.Equal(t, []types.Position{{Latitude: 51.4775, Longitude: -0.4614}}, layer.Features[0].Points)
+
+	assert.Equal(t, "09L/27R", layer.Features[1].Name)
+	assert.Equal(t, [][]types.Position{{{Latitude: 51.4775, Longitude: -0.4850}, {Latitude: 51.4776, Longitude: -0.4333}}}, layer.Features[1].Lines)
+
+	assert.Equal(t, "", layer.Features[2].Name)
+	assert.Len(t, layer.Features[2].Lines, 2)
+}
+
+func TestParseGeoJsonErrors(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1]}}`,
+		`{"type": "Feature", "geometry": {"type": "Circle", "coordinates": [1, 2]}}`,
+	}
+
+	for _, test := range tests {
+		_, err := ParseGeoJson("test", []byte(test))
+		assert.Error(t, err, "ParseGeoJson(%v) expected an error", test)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "airports.geojson"), []byte(testGeoJson), 0644)
+	if err != nil {
+		t.Error(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "coastlines.geojson"), []byte(`{"type": "LineString", "coordinates": [[0, 50], [1, 51]]}`), 0644)
+	if err != nil {
+		t.Error(err)
+	}
+
+	layers, err := LoadLayers(dir)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 2)
+	assert.Equal(t, "coastlines", layers[0].Name)
+	assert.Equal(t, "airports", layers[1].Name)
+
+	err = os.WriteFile(filepath.Join(dir, "borders.geojson"), []byte(`{`), 0644)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = LoadLayers(dir)
+	assert.Error(t, err)
+}
+
+func TestProject(t *testing.T) {
+	centre := types.Position{Latitude: 51.4775, Longitude: -0.4614}
+	tests := []struct {
+		projection string
+		pos        types.Position
+		expectedX  float64
+		expectedY  float64
+	}{
+		{
+			projection: Equirectangular,
+			pos:        centre,
+			expectedX:  0,
+			expectedY:  0,
+		},
+		{
+			projection: WebMercator,
+			pos:        centre,
+			expectedX:  0,
+			expectedY:  0,
+		},
+		{
+			projection: Equirectangular,
+			pos:        types.Position{Latitude: 52.4775, Longitude: -0.4614},
+			expectedX:  0,
+			expectedY:  111.19,
+		},
+		{
+			projection: Equirectangular,
+			pos:        types.Position{Latitude: 51.4775, Longitude: 0.5386},
+			expectedX:  69.26,
+			expectedY:  0,
+		},
+		{
+			projection: WebMercator,
+			pos:        types.Position{Latitude: 51.4775, Longitude: 0.5386},
+			expectedX:  69.26,
+			expectedY:  0,
+		},
+		{
+			projection: WebMercator,
+			pos:        types.Position{Latitude: 52.4775, Longitude: -0.4614},
+			expectedX:  0,
+			expectedY:  112.44,
+		},
+	}
+
+	for _, test := range tests {
+		res := Project(test.projection, centre, test.pos)
+		assert.InDelta(t, test.expectedX, res.X, 0.01, "Project(%v, %+v) X", test.projection, test.pos)
+		assert.InDelta(t, test.expectedY, res.Y, 0.01, "Project(%v, %+v) Y", test.projection, test.pos)
+	}
+}
+
+func TestProjectAcrossAntimeridian(t *testing.T) {
+	centre := types.Position{Latitude: 0, Longitude: 179.5}
+	res := Project(Equirectangular, centre, types.Position{Latitude: 0, Longitude: -179.5})
+	assert.InDelta(t, 111.19, res.X, 0.01)
+}
@@ -0,0 +1,61 @@
+package basemap
+
+import (
+	"math"
+	"planespotter/helpers/types"
+)
+
+const Equirectangular = "equirectangular"
+const WebMercator = "webmercator"
+
+const earthRadiusKm = 6371.0088
+const maxMercatorLatitude = 85.05112878
+
+// Point is a projected position, in km east (X) and north (Y) of the projection centre
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Project takes a projection name, the centre Position and a Position to project
+// Returns the projected Point relative to the centre, scaled so distances are true km at the centre
+// Unknown projection names fall back to Equirectangular
+func Project(projection string, centre, pos types.Position) Point {
+	lat0 := toRadians(centre.Latitude)
+	dLon := toRadians(normaliseLongitude(pos.Longitude - centre.Longitude))
+
+	if projection == WebMercator {
+		scale := earthRadiusKm * math.Cos(lat0)
+		return Point{
+			X: scale * dLon,
+			Y: scale * (mercatorY(pos.Latitude) - mercatorY(centre.Latitude)),
+		}
+	}
+
+	return Point{
+		X: earthRadiusKm * dLon * math.Cos(lat0),
+		Y: earthRadiusKm * toRadians(pos.Latitude-centre.Latitude),
+	}
+}
+
+// mercatorY takes a latitude in degrees and returns the unscaled Web Mercator y value
+// Latitudes are clamped to the Web Mercator limits as the poles would be infinitely far away
+func mercatorY(lat float64) float64 {
+	lat = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, lat))
+	return math.Log(math.Tan(math.Pi/4 + toRadians(lat)/2))
+}
+
+// normaliseLongitude takes a longitude difference in degrees and wraps it into -180..180
+// so features either side of the antimeridian are drawn next to each other
+func normaliseLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+
+	return lon - 180
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
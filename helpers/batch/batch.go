@@ -0,0 +1,143 @@
+// Package batch groups notifications for new planes, so a busy bank of departures doesn't send one notification
+// per plane: either one per check, or a digest every few minutes, with a cap on notifications per minute.
+
+package batch
+
+import (
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Each = "each plane"
+const PerCheck = "per check"
+const Digest = "digest"
+
+// Modes lists the ways new planes can be notified
+var Modes = []string{Each, PerCheck, Digest}
+
+// maxListed is how many callsigns a grouped message names before just giving the count of the rest
+const maxListed = 5
+
+// Notification is a message ready to send, about one or more planes
+type Notification struct {
+	Title   string
+	Message string
+	Planes  []types.PlaneInfo
+}
+
+// Batcher holds new planes until they are due to be notified, and remembers what has been sent for the cap
+type Batcher struct {
+	mu         sync.Mutex
+	pending    []pending
+	lastDigest time.Time
+	sent       []time.Time
+}
+
+type pending struct {
+	plane   types.PlaneInfo
+	title   string
+	message string
+}
+
+// Add takes a new plane and the title and message to send if it is notified on its own, and holds it until it is due
+func (b *Batcher) Add(p types.PlaneInfo, title, message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = append(b.pending, pending{plane: p, title: title, message: message})
+}
+
+// Due takes the Batching settings and the current time, and returns the notifications to send now
+// In Each mode every plane is notified on its own, in PerCheck mode all the planes added since the last call are
+// grouped, and in Digest mode they are grouped every DigestMinutes. When MaxPerMinute would be exceeded the
+// remaining planes are grouped into the last notification allowed, or held until the next minute if none are
+func (b *Batcher) Due(settings types.Batching, now time.Time) []Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	var groups [][]pending
+	switch settings.Mode {
+	case PerCheck:
+		groups = [][]pending{b.pending}
+	case Digest:
+		if b.lastDigest.IsZero() {
+			b.lastDigest = now
+		}
+		if now.Sub(b.lastDigest) < time.Duration(settings.DigestMinutes)*time.Minute {
+			return nil
+		}
+		b.lastDigest = now
+		groups = [][]pending{b.pending}
+	default:
+		for _, p := range b.pending {
+			groups = append(groups, []pending{p})
+		}
+	}
+
+	for len(b.sent) > 0 && now.Sub(b.sent[0]) >= time.Minute {
+		b.sent = b.sent[1:]
+	}
+	if settings.MaxPerMinute > 0 {
+		allowed := settings.MaxPerMinute - len(b.sent)
+		if allowed <= 0 {
+			return nil
+		}
+		if len(groups) > allowed {
+			var rest []pending
+			for _, g := range groups[allowed-1:] {
+				rest = append(rest, g...)
+			}
+			groups = append(groups[:allowed-1], rest)
+		}
+	}
+
+	var notifications []Notification
+	for _, g := range groups {
+		notifications = append(notifications, notification(g))
+		b.sent = append(b.sent, now)
+	}
+	b.pending = nil
+
+	return notifications
+}
+
+// List takes a slice of PlaneInfo and returns their callsigns separated by commas, ending with a count of the rest
+// if there are too many to list
+func List(planeInfos []types.PlaneInfo) string {
+	var callsigns []string
+	for i, p := range planeInfos {
+		if i == maxListed {
+			callsigns = append(callsigns, i18n.T("notify.and_more", len(planeInfos)-maxListed))
+			break
+		}
+		callsigns = append(callsigns, p.Callsign)
+	}
+
+	return strings.Join(callsigns, ", ")
+}
+
+// notification takes a group of pending planes and returns its Notification, using the plane's own title and
+// message for a single plane
+func notification(group []pending) Notification {
+	var planeInfos []types.PlaneInfo
+	for _, p := range group {
+		planeInfos = append(planeInfos, p.plane)
+	}
+
+	if len(group) == 1 {
+		return Notification{Title: group[0].title, Message: group[0].message, Planes: planeInfos}
+	}
+
+	return Notification{
+		Title:   i18n.T("notify.batch_title", len(group)),
+		Message: i18n.T("notify.batch_message", len(group), List(planeInfos)),
+		Planes:  planeInfos,
+	}
+}
@@ -0,0 +1,88 @@
+package batch
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func addPlanes(b *Batcher, callsigns ...string) {
+	for _, callsign := range callsigns {
+		b.Add(types.PlaneInfo{Callsign: callsign}, "Plane Spotted!", callsign+" details")
+	}
+}
+
+func TestDueEach(t *testing.T) {
+	var b Batcher
+	addPlanes(&b, "BAW1", "BAW2")
+
+	res := b.Due(types.Batching{}, t0)
+	assert.Len(t, res, 2)
+	assert.Equal(t, Notification{Title: "Plane Spotted!", Message: "BAW1 details", Planes: []types.PlaneInfo{{Callsign: "BAW1"}}}, res[0])
+	assert.Empty(t, b.Due(types.Batching{}, t0))
+}
+
+func TestDuePerCheck(t *testing.T) {
+	var b Batcher
+	addPlanes(&b, "BAW1", "BAW2", "BAW3")
+
+	res := b.Due(types.Batching{Mode: PerCheck}, t0)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "3 Planes Spotted!", res[0].Title)
+	assert.Equal(t, "3 new planes: BAW1, BAW2, BAW3", res[0].Message)
+
+	// A single plane still gets its own message
+	addPlanes(&b, "BAW4")
+	res = b.Due(types.Batching{Mode: PerCheck}, t0)
+	assert.Equal(t, "BAW4 details", res[0].Message)
+}
+
+func TestDueDigest(t *testing.T) {
+	var b Batcher
+	settings := types.Batching{Mode: Digest, DigestMinutes: 10}
+
+	addPlanes(&b, "BAW1")
+	assert.Empty(t, b.Due(settings, t0))
+	addPlanes(&b, "BAW2")
+	assert.Empty(t, b.Due(settings, t0.Add(9*time.Minute)))
+
+	res := b.Due(settings, t0.Add(10*time.Minute))
+	assert.Len(t, res, 1)
+	assert.Equal(t, "2 new planes: BAW1, BAW2", res[0].Message)
+
+	addPlanes(&b, "BAW3")
+	assert.Empty(t, b.Due(settings, t0.Add(15*time.Minute)))
+}
+
+func TestDueMaxPerMinute(t *testing.T) {
+	var b Batcher
+	settings := types.Batching{MaxPerMinute: 3}
+
+	addPlanes(&b, "BAW1", "BAW2", "BAW3", "BAW4", "BAW5")
+	res := b.Due(settings, t0)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "BAW2 details", res[1].Message)
+	assert.Equal(t, "3 new planes: BAW3, BAW4, BAW5", res[2].Message)
+
+	// Held until the minute is up
+	addPlanes(&b, "BAW6")
+	assert.Empty(t, b.Due(settings, t0.Add(30*time.Second)))
+	res = b.Due(settings, t0.Add(time.Minute))
+	assert.Len(t, res, 1)
+	assert.Equal(t, "BAW6 details", res[0].Message)
+}
+
+func TestList(t *testing.T) {
+	var planeInfos []types.PlaneInfo
+	for _, callsign := range []string{"BAW1", "BAW2", "BAW3", "BAW4", "BAW5", "BAW6", "BAW7"} {
+		planeInfos = append(planeInfos, types.PlaneInfo{Callsign: callsign})
+	}
+
+	assert.Equal(t, "BAW1, BAW2, BAW3, BAW4, BAW5, and 2 more", List(planeInfos))
+	assert.Equal(t, "BAW1, BAW2, BAW3, BAW4, BAW5", List(planeInfos[:5]))
+	assert.Equal(t, "", List(nil))
+}
@@ -0,0 +1,192 @@
+// Package budget tracks the OpenSky API credits used each day, and works out how often to check, or how small
+// to make the search area, so they last until they are reset at midnight UTC.
+
+package budget
+
+import (
+	"errors"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/types"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const Interval = "interval"
+const Area = "area"
+const Off = "off"
+
+// Adapts lists the ways spotting can adapt when credits run short: stretching the interval between checks,
+// shrinking the search area first, or not at all
+var Adapts = []string{Interval, Area, Off}
+
+// AnonymousCredits and AccountCredits are OpenSky's daily credits without and with an account
+const AnonymousCredits = 400
+const AccountCredits = 4000
+
+// ErrRateLimited is returned when the API refuses a request because there are no credits left
+var ErrRateLimited = errors.New("rate limited by the API")
+
+// DailyCredits takes the configured daily credits and the ApiAuth, and returns the credits to budget for each day:
+// the configured credits, or OpenSky's daily credits for the account if they aren't set
+func DailyCredits(configured int, auth types.ApiAuth) int {
+	switch {
+	case configured > 0:
+		return configured
+	case auth.Username != "" || auth.ClientId != "":
+		return AccountCredits
+	default:
+		return AnonymousCredits
+	}
+}
+
+// NextReset takes a time and returns when the credits are next reset, at midnight UTC
+func NextReset(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Tracker counts the credits spent each day. The API's own count of what is left is used when a response has
+// given one, otherwise the credits spent are taken from the daily credits. It is safe to use from more than one goroutine
+type Tracker struct {
+	mu         sync.Mutex
+	daily      int
+	day        time.Time
+	spent      int
+	remaining  int
+	known      bool
+	retryUntil time.Time
+}
+
+// NewTracker takes the daily credits and returns a Tracker for them
+func NewTracker(daily int) *Tracker {
+	return &Tracker{daily: daily}
+}
+
+// SetDaily takes the daily credits to budget for, keeping what has been spent today
+func (t *Tracker) SetDaily(daily int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.daily = daily
+}
+
+// Spend takes the credits a request cost and when it was made, and takes them off what is left today
+func (t *Tracker) Spend(credits int, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	t.spent += credits
+	t.remaining -= credits
+}
+
+// Observe takes the X-Rate-Limit-Remaining header from a response and when it was received, and uses it as
+// what is left today. The header already allows for the request's own cost. Returns false if the header is blank
+// or unreadable and so was ignored, when the request's cost should be Spent instead
+func (t *Tracker) Observe(header string, now time.Time) bool {
+	remaining, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+	t.remaining = remaining
+	t.known = true
+	return true
+}
+
+// RetryAfter takes the X-Rate-Limit-Retry-After-Seconds header from a refused request and when it was received,
+// and holds off checking until then. Without a readable header it holds off until the credits are reset
+func (t *Tracker) RetryAfter(header string, now time.Time) {
+	until := NextReset(now)
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds >= 0 {
+		until = now.Add(time.Duration(seconds) * time.Second)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.retryUntil = until
+}
+
+// Wait takes the time and returns how long until the API can be asked again, or 0 if it can be now
+func (t *Tracker) Wait(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Before(t.retryUntil) {
+		return t.retryUntil.Sub(now)
+	}
+
+	return 0
+}
+
+// Remaining takes the time and returns the credits left today, which is never less than 0
+func (t *Tracker) Remaining(now time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(now)
+
+	remaining := t.daily - t.spent
+	if t.known {
+		remaining = t.remaining
+	}
+	return max(remaining, 0)
+}
+
+// rollover starts counting again when a new day has started since the last request
+func (t *Tracker) rollover(now time.Time) {
+	y, m, d := now.UTC().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if !today.Equal(t.day) {
+		t.day = today
+		t.spent = 0
+		t.known = false
+	}
+}
+
+// CheckInterval takes the credits left, the credits each check costs, the shortest interval allowed and the time,
+// and returns how long to leave between checks so the credits last until they are reset
+// With no credits left it waits until they are reset
+func CheckInterval(remaining, cost int, minimum time.Duration, now time.Time) time.Duration {
+	if cost <= 0 {
+		return minimum
+	}
+
+	untilReset := NextReset(now).Sub(now)
+	checks := remaining / cost
+	if checks <= 0 {
+		return untilReset
+	}
+
+	interval := (untilReset / time.Duration(checks)).Round(time.Second)
+	return max(interval, minimum)
+}
+
+// Exhaustion takes the credits left, the credits each check costs, the interval between checks and the time,
+// and returns when the credits will run out at that rate, and false if they will last until they are reset
+func Exhaustion(remaining, cost int, interval time.Duration, now time.Time) (time.Time, bool) {
+	if cost <= 0 {
+		return time.Time{}, false
+	}
+
+	at := now.Add(time.Duration(remaining/cost) * interval)
+	if !at.Before(NextReset(now)) {
+		return at, false
+	}
+	return at, true
+}
+
+// Shrink takes an Area and the most credits a query for it may cost, and returns it with its spot distance
+// reduced until a query costs no more, down to 1 km. Shaped areas are returned as they are, as they can't
+// be shrunk without changing their shape
+func Shrink(a types.Area, maxCredits int) types.Area {
+	if areas.IsShaped(a) {
+		return a
+	}
+
+	for a.SpotDistanceKm > 1 && areas.Credits(areas.Box(a)) > maxCredits {
+		a.SpotDistanceKm--
+	}
+	return a
+}
@@ -0,0 +1,105 @@
+package budget
+
+import (
+	"planespotter/helpers/areas"
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyCredits(t *testing.T) {
+	assert.Equal(t, AnonymousCredits, DailyCredits(0, types.ApiAuth{}))
+	assert.Equal(t, AccountCredits, DailyCredits(0, types.ApiAuth{Username: "spotter"}))
+	assert.Equal(t, AccountCredits, DailyCredits(0, types.ApiAuth{ClientId: "spotter-api-client"}))
+	assert.Equal(t, 8000, DailyCredits(8000, types.ApiAuth{Username: "spotter"}))
+}
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(400)
+	assert.Equal(t, 400, tracker.Remaining(now))
+
+	tracker.Spend(3, now)
+	assert.Equal(t, 397, tracker.Remaining(now))
+
+	// The API's count is trusted over our own, and credits spent after it are taken off it
+	assert.True(t, tracker.Observe("250", now))
+	assert.Equal(t, 250, tracker.Remaining(now))
+	tracker.Spend(2, now)
+	assert.Equal(t, 248, tracker.Remaining(now))
+	assert.False(t, tracker.Observe("", now))
+	assert.Equal(t, 248, tracker.Remaining(now))
+
+	// Everything is reset at midnight UTC
+	tomorrow := time.Date(2026, 10, 20, 0, 0, 1, 0, time.UTC)
+	assert.Equal(t, 400, tracker.Remaining(tomorrow))
+
+	tracker.Spend(500, tomorrow)
+	assert.Equal(t, 0, tracker.Remaining(tomorrow))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(400)
+	assert.Equal(t, time.Duration(0), tracker.Wait(now))
+
+	tracker.RetryAfter("90", now)
+	assert.Equal(t, 90*time.Second, tracker.Wait(now))
+	assert.Equal(t, 30*time.Second, tracker.Wait(now.Add(time.Minute)))
+	assert.Equal(t, time.Duration(0), tracker.Wait(now.Add(2*time.Minute)))
+
+	// Without a header, wait until the credits are reset
+	tracker.RetryAfter("", now)
+	assert.Equal(t, 12*time.Hour, tracker.Wait(now))
+}
+
+func TestCheckInterval(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		remaining int
+		cost      int
+		expected  time.Duration
+	}{
+		// 12 hours left: 8000 checks are plenty, so the minimum is used, but 4000 aren't quite
+		{remaining: 8000, cost: 1, expected: 10 * time.Second},
+		{remaining: 4000, cost: 1, expected: 11 * time.Second},
+		// 360 checks over 12 hours is one every 2 minutes
+		{remaining: 360, cost: 1, expected: 2 * time.Minute},
+		{remaining: 720, cost: 2, expected: 2 * time.Minute},
+		{remaining: 1, cost: 2, expected: 12 * time.Hour},
+		{remaining: 0, cost: 1, expected: 12 * time.Hour},
+		{remaining: 0, cost: 0, expected: 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, CheckInterval(test.remaining, test.cost, 10*time.Second, now), "%v credits at %v a check", test.remaining, test.cost)
+	}
+}
+
+func TestExhaustion(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	at, ok := Exhaustion(360, 1, time.Minute, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC), at)
+
+	_, ok = Exhaustion(360, 1, 2*time.Minute, now)
+	assert.False(t, ok)
+	_, ok = Exhaustion(360, 0, time.Minute, now)
+	assert.False(t, ok)
+}
+
+func TestShrink(t *testing.T) {
+	area := types.Area{Position: types.Position{Latitude: 60, Longitude: 10}, SpotDistanceKm: 500}
+	assert.Equal(t, 3, areas.Credits(areas.Box(area)))
+
+	shrunk := Shrink(area, 1)
+	assert.Equal(t, 1, areas.Credits(areas.Box(shrunk)))
+	assert.Equal(t, 2, areas.Credits(areas.Box(types.Area{Position: area.Position, SpotDistanceKm: shrunk.SpotDistanceKm + 1})))
+	assert.Equal(t, area, Shrink(area, 3))
+
+	shaped := types.Area{Position: area.Position, SpotDistanceKm: 500, Shape: types.SpotShape{Type: areas.Polygon, Points: []types.Position{{Latitude: 40, Longitude: -10}, {Latitude: 70, Longitude: -10}, {Latitude: 70, Longitude: 30}}}}
+	assert.Equal(t, shaped, Shrink(shaped, 1))
+}
@@ -0,0 +1,239 @@
+// Package export writes recorded sightings to formats other tools understand, such as KML for Google Earth,
+// GPX for GIS tools and CSV or JSON Lines for spreadsheets, and reads them back in to merge logbooks.
+
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/states"
+	"planespotter/helpers/types"
+	"strings"
+	"time"
+)
+
+// Filter takes a slice of Sighting, a time range and a list of callsigns or icao24 addresses
+// Returns the sightings that overlap the time range and match one of the callsigns
+// A zero from or to leaves that end of the range open, and an empty callsigns list matches every sighting
+func Filter(sightings []types.Sighting, from, to time.Time, callsigns []string) []types.Sighting {
+	var filtered []types.Sighting
+	for _, s := range sightings {
+		if !from.IsZero() && s.LastSeen.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !s.FirstSeen.Before(to) {
+			continue
+		}
+		if len(callsigns) > 0 && !matchesAny(s, callsigns) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+
+	return filtered
+}
+
+// matchesAny takes a Sighting and returns true if its callsign or icao24 matches any of the given values, ignoring case
+func matchesAny(s types.Sighting, callsigns []string) bool {
+	for _, c := range callsigns {
+		c = strings.TrimSpace(c)
+		if strings.EqualFold(c, s.Callsign) || strings.EqualFold(c, s.Icao24) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type kml struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name      string         `xml:"name"`
+	Style     kmlStyle       `xml:"Style"`
+	Placemark []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	Id        string       `xml:"id,attr"`
+	LineStyle kmlLineStyle `xml:"LineStyle"`
+	PolyStyle kmlPolyStyle `xml:"PolyStyle"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlPolyStyle struct {
+	Color string `xml:"color"`
+}
+
+type kmlPlacemark struct {
+	Name         string         `xml:"name"`
+	Description  string         `xml:"description"`
+	TimeSpan     *kmlTimeSpan   `xml:"TimeSpan,omitempty"`
+	StyleUrl     string         `xml:"styleUrl,omitempty"`
+	LineString   *kmlLineString `xml:"LineString,omitempty"`
+	Point        *kmlPoint      `xml:"Point,omitempty"`
+	ExtendedData []kmlData      `xml:"ExtendedData>Data"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin"`
+	End   string `xml:"end"`
+}
+
+type kmlLineString struct {
+	Extrude      int    `xml:"extrude"`
+	Tessellate   int    `xml:"tessellate"`
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+type kmlPoint struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// WriteKml takes a Writer, a slice of Sighting and the Units to describe them in, and writes them as a KML document
+// Each track is an altitude-extruded LineString, with a placemark where the plane was first seen
+// The plane's type is its aircraft category, as OpenSky doesn't give the model, so is only known with extended states
+// Descriptions show the category in the current language, while its data keeps the name it is saved with
+// Coordinates are always in degrees and metres as KML requires, the Units only change the descriptions
+func WriteKml(w io.Writer, sightings []types.Sighting, units types.Units) error {
+	doc := kml{
+		Xmlns: "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{
+			Name:  "Planespotter sightings",
+			Style: kmlStyle{Id: "track", LineStyle: kmlLineStyle{Color: "ff3030d9", Width: 2}, PolyStyle: kmlPolyStyle{Color: "403030d9"}},
+		},
+	}
+
+	for _, s := range sightings {
+		data := []kmlData{
+			{Name: "callsign", Value: s.Callsign},
+			{Name: "icao24", Value: s.Icao24},
+			{Name: "firstSeen", Value: formatTime(s.FirstSeen)},
+			{Name: "lastSeen", Value: formatTime(s.LastSeen)},
+		}
+		timeSpan := &kmlTimeSpan{Begin: formatTime(s.FirstSeen), End: formatTime(s.LastSeen)}
+		description := fmt.Sprintf("%v (%v) seen %v to %v", s.Callsign, s.Icao24, formatTime(s.FirstSeen), formatTime(s.LastSeen))
+		if s.Category != "" {
+			data = append(data, kmlData{Name: "category", Value: s.Category})
+			description = fmt.Sprintf("%v (%v), %v, seen %v to %v", s.Callsign, s.Icao24, states.CategoryLabel(s.Category), formatTime(s.FirstSeen), formatTime(s.LastSeen))
+		}
+
+		var coords []string
+		for _, point := range s.Track {
+			coords = append(coords, kmlCoordinate(point))
+		}
+		if len(coords) > 1 {
+			doc.Document.Placemark = append(doc.Document.Placemark, kmlPlacemark{
+				Name:         s.Callsign,
+				Description:  description,
+				TimeSpan:     timeSpan,
+				StyleUrl:     "#track",
+				LineString:   &kmlLineString{Extrude: 1, Tessellate: 1, AltitudeMode: "absolute", Coordinates: strings.Join(coords, " ")},
+				ExtendedData: data,
+			})
+		}
+
+		if len(s.Track) > 0 {
+			doc.Document.Placemark = append(doc.Document.Placemark, kmlPlacemark{
+				Name:         s.Callsign,
+				Description:  fmt.Sprintf("%v, closest approach %v", description, formatters.FormatDistance(s.ClosestApproachKm, units.Distance)),
+				TimeSpan:     timeSpan,
+				Point:        &kmlPoint{AltitudeMode: "absolute", Coordinates: kmlCoordinate(s.Track[0])},
+				ExtendedData: data,
+			})
+		}
+	}
+
+	return writeXml(w, doc)
+}
+
+type gpx struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Creator string     `xml:"creator,attr"`
+	Tracks  []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Desc     string       `xml:"desc"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Ele  float64 `xml:"ele"`
+	Time string  `xml:"time"`
+}
+
+// WriteGpx takes a Writer and a slice of Sighting, and writes them as a GPX 1.1 document
+// Each sighting is a track with elevation in metres and a timestamp on every point
+func WriteGpx(w io.Writer, sightings []types.Sighting) error {
+	doc := gpx{Xmlns: "http://www.topografix.com/GPX/1/1", Version: "1.1", Creator: "planespotter"}
+
+	for _, s := range sightings {
+		if len(s.Track) == 0 {
+			continue
+		}
+
+		var segment gpxSegment
+		for _, point := range s.Track {
+			segment.Points = append(segment.Points, gpxPoint{Lat: point.Latitude, Lon: point.Longitude, Ele: point.Altitude, Time: formatTime(point.Time)})
+		}
+		doc.Tracks = append(doc.Tracks, gpxTrack{
+			Name:     s.Callsign,
+			Desc:     fmt.Sprintf("%v seen %v to %v", s.Icao24, formatTime(s.FirstSeen), formatTime(s.LastSeen)),
+			Segments: []gpxSegment{segment},
+		})
+	}
+
+	return writeXml(w, doc)
+}
+
+// writeXml takes a Writer and a document, and writes the document as indented XML with a header
+func writeXml(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// kmlCoordinate takes a TrackPoint and returns it as a KML "lon,lat,alt" coordinate
+func kmlCoordinate(point types.TrackPoint) string {
+	return fmt.Sprintf("%.5f,%.5f,%.0f", point.Longitude, point.Latitude, point.Altitude)
+}
+
+// formatTime takes a time and returns it in UTC RFC 3339 format, as used by both KML and GPX
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
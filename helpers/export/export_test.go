@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+var testSightings = []types.Sighting{
+	{
+		Icao24:            "abc123",
+		Callsign:          "BAW12",
+		Category:          "large",
+		FirstSeen:         t0,
+		LastSeen:          t0.Add(2 * time.Minute),
+		ClosestApproachKm: 1.5,
+		Track: []types.TrackPoint{
+			{Time: t0, Latitude: 51.0, Longitude: -0.5, Altitude: 1000},
+			{Time: t0.Add(time.Minute), Latitude: 51.1, Longitude: -0.4, Altitude: 1200},
+			{Time: t0.Add(2 * time.Minute), Latitude: 51.2, Longitude: -0.3, Altitude: 1400},
+		},
+	},
+	{
+		Icao24:    "def456",
+		Callsign:  "EZY34",
+		FirstSeen: t0.Add(24 * time.Hour),
+		LastSeen:  t0.Add(24*time.Hour + time.Minute),
+	},
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		from      time.Time
+		to        time.Time
+		callsigns []string
+		expected  []string
+	}{
+		{
+			expected: []string{"abc123", "def456"},
+		},
+		{
+			from:     t0.Add(time.Hour),
+			expected: []string{"def456"},
+		},
+		{
+			to:       t0.Add(time.Hour),
+			expected: []string{"abc123"},
+		},
+		{
+			from:     t0.Add(time.Minute),
+			to:       t0.Add(time.Hour),
+			expected: []string{"abc123"},
+		},
+		{
+			callsigns: []string{"ezy34"},
+			expected:  []string{"def456"},
+		},
+		{
+			callsigns: []string{"ABC123"},
+			expected:  []string{"abc123"},
+		},
+		{
+			callsigns: []string{"RYR1"},
+			expected:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		var res []string
+		for _, s := range Filter(testSightings, test.from, test.to, test.callsigns) {
+			res = append(res, s.Icao24)
+		}
+		assert.Equal(t, test.expected, res, "Filter(%v, %v, %v)", test.from, test.to, test.callsigns)
+	}
+}
+
+func TestWriteKml(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteKml(&buf, testSightings, types.Units{})
+	assert.NoError(t, err)
+
+	var doc kml
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	// Track and first seen placemark for BAW12, nothing for EZY34 which has no track
+	assert.Len(t, doc.Document.Placemark, 2)
+	assert.Equal(t, "BAW12", doc.Document.Placemark[0].Name)
+	assert.Equal(t, 1, doc.Document.Placemark[0].LineString.Extrude)
+	assert.Equal(t, "absolute", doc.Document.Placemark[0].LineString.AltitudeMode)
+	assert.Equal(t, "-0.50000,51.00000,1000 -0.40000,51.10000,1200 -0.30000,51.20000,1400", doc.Document.Placemark[0].LineString.Coordinates)
+	assert.Equal(t, "2026-10-19T12:00:00Z", doc.Document.Placemark[0].TimeSpan.Begin)
+	assert.Equal(t, "-0.50000,51.00000,1000", doc.Document.Placemark[1].Point.Coordinates)
+	assert.Contains(t, doc.Document.Placemark[1].Description, "closest approach 1.5 km")
+	assert.Contains(t, doc.Document.Placemark[0].Description, "BAW12 (abc123), Large, seen")
+	assert.Contains(t, doc.Document.Placemark[0].ExtendedData, kmlData{Name: "category", Value: "large"})
+
+	// Descriptions use the distance unit, coordinates stay in metres
+	buf.Reset()
+	assert.NoError(t, WriteKml(&buf, testSightings, types.Units{Distance: "nm"}))
+	var nmDoc kml
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &nmDoc))
+	assert.Contains(t, nmDoc.Document.Placemark[1].Description, "closest approach 0.8 nm")
+	assert.Equal(t, "-0.50000,51.00000,1000", nmDoc.Document.Placemark[1].Point.Coordinates)
+}
+
+func TestWriteGpx(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteGpx(&buf, testSightings)
+	assert.NoError(t, err)
+
+	var doc gpx
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "1.1", doc.Version)
+	assert.Len(t, doc.Tracks, 1)
+	assert.Equal(t, "BAW12", doc.Tracks[0].Name)
+	assert.Equal(t, []gpxPoint{
+		{Lat: 51.0, Lon: -0.5, Ele: 1000, Time: "2026-10-19T12:00:00Z"},
+		{Lat: 51.1, Lon: -0.4, Ele: 1200, Time: "2026-10-19T12:01:00Z"},
+		{Lat: 51.2, Lon: -0.3, Ele: 1400, Time: "2026-10-19T12:02:00Z"},
+	}, doc.Tracks[0].Segments[0].Points)
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const Sightings = "sightings"
+const Airframes = "airframes"
+const Stats = "stats"
+
+// Datasets lists the parts of the logbook that can be written with WriteCsv and WriteJsonLines
+var Datasets = []string{Sightings, Airframes, Stats}
+
+var sightingsHeader = []string{"icao24", "callsign", "first_seen", "last_seen", "closest_approach_km", "track_points", "profile", "period", "category"}
+
+// olderSightingsHeader is the header of sightings CSVs written before they had a profile, period and category
+var olderSightingsHeader = sightingsHeader[:6]
+
+// Airframe summarises every sighting of one icao24 address
+type Airframe struct {
+	Icao24    string
+	Callsigns []string
+	Sightings int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Summary is the overall numbers for a logbook
+type Summary struct {
+	SeenCount int
+	Callsigns int
+	Airframes int
+	Sightings int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Day       int
+	Night     int
+}
+
+// SummariseAirframes takes a slice of Sighting and returns one Airframe per icao24 address, sorted by icao24
+func SummariseAirframes(sightings []types.Sighting) []Airframe {
+	byIcao24 := make(map[string]*Airframe)
+	for _, s := range sightings {
+		a, ok := byIcao24[s.Icao24]
+		if !ok {
+			a = &Airframe{Icao24: s.Icao24, FirstSeen: s.FirstSeen, LastSeen: s.LastSeen}
+			byIcao24[s.Icao24] = a
+		}
+		a.Sightings++
+		if s.FirstSeen.Before(a.FirstSeen) {
+			a.FirstSeen = s.FirstSeen
+		}
+		if s.LastSeen.After(a.LastSeen) {
+			a.LastSeen = s.LastSeen
+		}
+		if !slices.Contains(a.Callsigns, s.Callsign) {
+			a.Callsigns = append(a.Callsigns, s.Callsign)
+		}
+	}
+
+	var airframes []Airframe
+	for _, a := range byIcao24 {
+		airframes = append(airframes, *a)
+	}
+	sort.Slice(airframes, func(i, j int) bool { return airframes[i].Icao24 < airframes[j].Icao24 })
+
+	return airframes
+}
+
+// Summarise takes the total seen count from the save and a slice of Sighting, and returns a Summary of them
+// Day and Night count the sightings tagged with each period, so untagged older sightings are in neither
+func Summarise(seenCount int, sightings []types.Sighting) Summary {
+	summary := Summary{SeenCount: seenCount, Sightings: len(sightings)}
+
+	var callsigns []string
+	for _, s := range sightings {
+		if !slices.Contains(callsigns, s.Callsign) {
+			callsigns = append(callsigns, s.Callsign)
+		}
+		if summary.FirstSeen.IsZero() || s.FirstSeen.Before(summary.FirstSeen) {
+			summary.FirstSeen = s.FirstSeen
+		}
+		if s.LastSeen.After(summary.LastSeen) {
+			summary.LastSeen = s.LastSeen
+		}
+		switch s.Period {
+		case sun.Day:
+			summary.Day++
+		case sun.Night:
+			summary.Night++
+		}
+	}
+	summary.Callsigns = len(callsigns)
+	summary.Airframes = len(SummariseAirframes(sightings))
+
+	return summary
+}
+
+// WriteCsv takes a Writer, a dataset name (one of Datasets), the total seen count and a slice of Sighting,
+// and writes the dataset as CSV with a header row. Tracks are not included, only the number of points
+func WriteCsv(w io.Writer, dataset string, seenCount int, sightings []types.Sighting) error {
+	cw := csv.NewWriter(w)
+
+	switch dataset {
+	case Sightings:
+		cw.Write(sightingsHeader)
+		for _, s := range sightings {
+			cw.Write([]string{s.Icao24, s.Callsign, formatTime(s.FirstSeen), formatTime(s.LastSeen), strconv.FormatFloat(s.ClosestApproachKm, 'f', 2, 64), strconv.Itoa(len(s.Track)), s.Profile, s.Period, s.Category})
+		}
+	case Airframes:
+		cw.Write([]string{"icao24", "callsigns", "sightings", "first_seen", "last_seen"})
+		for _, a := range SummariseAirframes(sightings) {
+			cw.Write([]string{a.Icao24, strings.Join(a.Callsigns, ";"), strconv.Itoa(a.Sightings), formatTime(a.FirstSeen), formatTime(a.LastSeen)})
+		}
+	case Stats:
+		summary := Summarise(seenCount, sightings)
+		cw.Write([]string{"seen_count", "callsigns", "airframes", "sightings", "first_seen", "last_seen", "day", "night"})
+		cw.Write([]string{strconv.Itoa(summary.SeenCount), strconv.Itoa(summary.Callsigns), strconv.Itoa(summary.Airframes), strconv.Itoa(summary.Sightings), formatTime(summary.FirstSeen), formatTime(summary.LastSeen), strconv.Itoa(summary.Day), strconv.Itoa(summary.Night)})
+	default:
+		return fmt.Errorf("unknown dataset %q, expected one of %v", dataset, strings.Join(Datasets, ", "))
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJsonLines takes a Writer, a dataset name (one of Datasets), the total seen count and a slice of Sighting,
+// and writes the dataset as JSON Lines, one object per line. Sightings include their full tracks
+func WriteJsonLines(w io.Writer, dataset string, seenCount int, sightings []types.Sighting) error {
+	var records []interface{}
+	switch dataset {
+	case Sightings:
+		for _, s := range sightings {
+			records = append(records, s)
+		}
+	case Airframes:
+		for _, a := range SummariseAirframes(sightings) {
+			records = append(records, a)
+		}
+	case Stats:
+		records = append(records, Summarise(seenCount, sightings))
+	default:
+		return fmt.Errorf("unknown dataset %q, expected one of %v", dataset, strings.Join(Datasets, ", "))
+	}
+
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadSightingsCsv takes a Reader of a sightings CSV as written by WriteCsv, and returns the sightings in it
+// Older CSVs without the profile, period and category columns are read too, leaving them blank
+// Returns an error if the header doesn't match or a row can't be parsed
+func ReadSightingsCsv(r io.Reader) ([]types.Sighting, error) {
+	cr := csv.NewReader(r)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || !(slices.Equal(rows[0], sightingsHeader) || slices.Equal(rows[0], olderSightingsHeader)) {
+		return nil, fmt.Errorf("not a sightings CSV, expected header %v", strings.Join(sightingsHeader, ","))
+	}
+
+	var sightings []types.Sighting
+	for i, row := range rows[1:] {
+		var s types.Sighting
+		s.Icao24, s.Callsign = row[0], row[1]
+		if s.FirstSeen, err = time.Parse(time.RFC3339, row[2]); err != nil {
+			return sightings, fmt.Errorf("row %v: invalid first_seen: %w", i+2, err)
+		}
+		if s.LastSeen, err = time.Parse(time.RFC3339, row[3]); err != nil {
+			return sightings, fmt.Errorf("row %v: invalid last_seen: %w", i+2, err)
+		}
+		if s.ClosestApproachKm, err = strconv.ParseFloat(row[4], 64); err != nil {
+			return sightings, fmt.Errorf("row %v: invalid closest_approach_km: %w", i+2, err)
+		}
+		if len(row) == len(sightingsHeader) {
+			s.Profile, s.Period, s.Category = row[6], row[7], row[8]
+		}
+		sightings = append(sightings, s)
+	}
+
+	return sightings, nil
+}
+
+// ReadSightingsJsonLines takes a Reader of sightings JSON Lines as written by WriteJsonLines, and returns the sightings in it
+// Blank lines are skipped. Returns an error if a line isn't a sighting
+func ReadSightingsJsonLines(r io.Reader) ([]types.Sighting, error) {
+	var sightings []types.Sighting
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Long tracks make for long lines
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var s types.Sighting
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return sightings, fmt.Errorf("line %v: %w", line, err)
+		}
+		if s.Icao24 == "" {
+			return sightings, fmt.Errorf("line %v: not a sighting, missing Icao24", line)
+		}
+		sightings = append(sightings, s)
+	}
+
+	return sightings, scanner.Err()
+}
+
+// Merge takes the current Progress and imported sightings, and returns the Progress with them merged in
+// An imported sighting of the same icao24 at an overlapping time to an existing one is the same pass, maybe
+// seen from another location, so the two are combined rather than counted twice
+// Callsigns from the imported sightings are added, and SeenCount updated to match
+func Merge(progress types.Progress, imported []types.Sighting) types.Progress {
+	for _, s := range imported {
+		i := slices.IndexFunc(progress.Sightings, func(existing types.Sighting) bool {
+			return existing.Icao24 == s.Icao24 && !existing.LastSeen.Before(s.FirstSeen) && !s.LastSeen.Before(existing.FirstSeen)
+		})
+
+		if i == -1 {
+			progress.Sightings = append(progress.Sightings, s)
+		} else {
+			existing := &progress.Sightings[i]
+			if s.FirstSeen.Before(existing.FirstSeen) {
+				existing.FirstSeen = s.FirstSeen
+			}
+			if s.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = s.LastSeen
+			}
+			if len(s.Track) > len(existing.Track) {
+				existing.Track = s.Track
+			}
+			if existing.ClosestApproachKm == 0 || (s.ClosestApproachKm > 0 && s.ClosestApproachKm < existing.ClosestApproachKm) {
+				existing.ClosestApproachKm = s.ClosestApproachKm
+			}
+		}
+
+		if !slices.Contains(progress.Callsigns, s.Callsign) {
+			progress.Callsigns = append(progress.Callsigns, s.Callsign)
+			progress.SeenCount++
+		}
+	}
+
+	sort.SliceStable(progress.Sightings, func(i, j int) bool {
+		return progress.Sightings[i].FirstSeen.Before(progress.Sightings[j].FirstSeen)
+	})
+
+	return progress
+}
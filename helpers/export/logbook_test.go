@@ -0,0 +1,153 @@
+package export
+
+import (
+	"bytes"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummariseAirframes(t *testing.T) {
+	sightings := append(testSightings, types.Sighting{Icao24: "abc123", Callsign: "BAW12A", FirstSeen: t0.Add(48 * time.Hour), LastSeen: t0.Add(49 * time.Hour)})
+
+	res := SummariseAirframes(sightings)
+	assert.Equal(t, []Airframe{
+		{Icao24: "abc123", Callsigns: []string{"BAW12", "BAW12A"}, Sightings: 2, FirstSeen: t0, LastSeen: t0.Add(49 * time.Hour)},
+		{Icao24: "def456", Callsigns: []string{"EZY34"}, Sightings: 1, FirstSeen: t0.Add(24 * time.Hour), LastSeen: t0.Add(24*time.Hour + time.Minute)},
+	}, res)
+}
+
+func TestSummarise(t *testing.T) {
+	res := Summarise(10, testSightings)
+	assert.Equal(t, Summary{SeenCount: 10, Callsigns: 2, Airframes: 2, Sightings: 2, FirstSeen: t0, LastSeen: t0.Add(24*time.Hour + time.Minute)}, res)
+
+	tagged := []types.Sighting{{Callsign: "BAW12", Period: sun.Day}, {Callsign: "EZY34", Period: sun.Night}, {Callsign: "RYR56", Period: sun.Day}}
+	res = Summarise(3, tagged)
+	assert.Equal(t, 2, res.Day)
+	assert.Equal(t, 1, res.Night)
+}
+
+func TestWriteCsv(t *testing.T) {
+	tests := []struct {
+		dataset  string
+		expected string
+	}{
+		{
+			dataset: Sightings,
+			expected: "icao24,callsign,first_seen,last_seen,closest_approach_km,track_points,profile,period,category\n" +
+				"abc123,BAW12,2026-10-19T12:00:00Z,2026-10-19T12:02:00Z,1.50,3,,,large\n" +
+				"def456,EZY34,2026-10-20T12:00:00Z,2026-10-20T12:01:00Z,0.00,0,,,\n",
+		},
+		{
+			dataset: Airframes,
+			expected: "icao24,callsigns,sightings,first_seen,last_seen\n" +
+				"abc123,BAW12,1,2026-10-19T12:00:00Z,2026-10-19T12:02:00Z\n" +
+				"def456,EZY34,1,2026-10-20T12:00:00Z,2026-10-20T12:01:00Z\n",
+		},
+		{
+			dataset: Stats,
+			expected: "seen_count,callsigns,airframes,sightings,first_seen,last_seen,day,night\n" +
+				"5,2,2,2,2026-10-19T12:00:00Z,2026-10-20T12:01:00Z,0,0\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := WriteCsv(&buf, test.dataset, 5, testSightings)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, buf.String())
+	}
+
+	assert.Error(t, WriteCsv(&bytes.Buffer{}, "planes", 5, testSightings))
+}
+
+func TestWriteJsonLines(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJsonLines(&buf, Stats, 5, testSightings)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"SeenCount":5,"Callsigns":2,"Airframes":2,"Sightings":2,"FirstSeen":"2026-10-19T12:00:00Z","LastSeen":"2026-10-20T12:01:00Z","Day":0,"Night":0}`+"\n", buf.String())
+
+	buf.Reset()
+	err = WriteJsonLines(&buf, Airframes, 5, testSightings)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+
+	assert.Error(t, WriteJsonLines(&bytes.Buffer{}, "planes", 5, testSightings))
+}
+
+func TestSightingsRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJsonLines(&buf, Sightings, 5, testSightings)
+	assert.NoError(t, err)
+
+	res, err := ReadSightingsJsonLines(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, testSightings, res)
+
+	buf.Reset()
+	err = WriteCsv(&buf, Sightings, 5, testSightings)
+	assert.NoError(t, err)
+
+	res, err = ReadSightingsCsv(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "BAW12", res[0].Callsign)
+	assert.Equal(t, t0, res[0].FirstSeen)
+	assert.Equal(t, 1.5, res[0].ClosestApproachKm)
+	assert.Empty(t, res[0].Track)
+
+	// Day and night stats, the profile and the category survive a CSV round trip
+	tagged := []types.Sighting{{Icao24: "abc123", Callsign: "BAW12", FirstSeen: t0, LastSeen: t0, Profile: "Home", Period: sun.Night, Category: "large"}}
+	buf.Reset()
+	assert.NoError(t, WriteCsv(&buf, Sightings, 1, tagged))
+	res, err = ReadSightingsCsv(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, tagged, res)
+
+	// CSVs from before those columns are still read
+	res, err = ReadSightingsCsv(strings.NewReader("icao24,callsign,first_seen,last_seen,closest_approach_km,track_points\nabc123,BAW12,2026-10-19T12:00:00Z,2026-10-19T12:00:00Z,1.50,0\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Sighting{{Icao24: "abc123", Callsign: "BAW12", FirstSeen: t0, LastSeen: t0, ClosestApproachKm: 1.5}}, res)
+}
+
+func TestReadSightingsErrors(t *testing.T) {
+	_, err := ReadSightingsCsv(strings.NewReader("icao24,callsigns,sightings,first_seen,last_seen\n"))
+	assert.Error(t, err)
+
+	_, err = ReadSightingsCsv(strings.NewReader("icao24,callsign,first_seen,last_seen,closest_approach_km,track_points\nabc123,BAW12,yesterday,today,1,0\n"))
+	assert.Error(t, err)
+
+	_, err = ReadSightingsJsonLines(strings.NewReader(`{"SeenCount":5}`))
+	assert.Error(t, err)
+
+	_, err = ReadSightingsJsonLines(strings.NewReader(`{`))
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	progress := types.Progress{SeenCount: 1, Callsigns: []string{"BAW12"}, Sightings: []types.Sighting{testSightings[0]}}
+
+	imported := []types.Sighting{
+		// Same pass of abc123 seen from another location, for longer
+		{Icao24: "abc123", Callsign: "BAW12", FirstSeen: t0.Add(-time.Minute), LastSeen: t0.Add(time.Minute), ClosestApproachKm: 0.5},
+		// A later pass of abc123 is a separate sighting
+		{Icao24: "abc123", Callsign: "BAW12", FirstSeen: t0.Add(time.Hour), LastSeen: t0.Add(time.Hour + time.Minute)},
+		{Icao24: "def456", Callsign: "EZY34", FirstSeen: t0.Add(24 * time.Hour), LastSeen: t0.Add(24*time.Hour + time.Minute)},
+	}
+
+	res := Merge(progress, imported)
+	assert.Equal(t, 2, res.SeenCount)
+	assert.Equal(t, []string{"BAW12", "EZY34"}, res.Callsigns)
+	assert.Len(t, res.Sightings, 3)
+	assert.Equal(t, t0.Add(-time.Minute), res.Sightings[0].FirstSeen)
+	assert.Equal(t, t0.Add(2*time.Minute), res.Sightings[0].LastSeen)
+	assert.Equal(t, 0.5, res.Sightings[0].ClosestApproachKm)
+	assert.Len(t, res.Sightings[0].Track, 3)
+
+	// Importing the same data again changes nothing
+	assert.Equal(t, res, Merge(res, imported))
+}
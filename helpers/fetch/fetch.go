@@ -0,0 +1,141 @@
+// Package fetch makes API requests with timeouts, sorts what goes wrong into kinds of Error so callers can tell
+// a dropped connection from a bad password, and works out how long to back off before trying again.
+
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+const Network = "network"
+const Auth = "auth"
+const RateLimit = "rate limit"
+const Server = "server"
+const Parse = "parse"
+
+// Kinds lists the kinds of Error
+var Kinds = []string{Network, Auth, RateLimit, Server, Parse}
+
+// DefaultTimeout is how long a whole request, including reading the response, can take
+const DefaultTimeout = 20 * time.Second
+
+// Error is a failed request, with the Kind of failure and the response's Status if there was one
+type Error struct {
+	Kind   string
+	Status int
+	Err    error
+}
+
+// Error returns the kind of failure and what went wrong
+func (e *Error) Error() string {
+	if e.Status != 0 {
+		return fmt.Sprintf("%v error: status %v: %v", e.Kind, e.Status, e.Err)
+	}
+	return fmt.Sprintf("%v error: %v", e.Kind, e.Err)
+}
+
+// Unwrap returns what went wrong, so errors.Is and errors.As see through the Error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable returns true if trying again later could work: the network or server failing, or running out of credits
+// A rejected sign in, a request the server won't ever accept, or a response that can't be read will fail the same
+// way until something is changed
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case Network, RateLimit:
+		return true
+	case Server:
+		return e.Status == 0 || e.Status == http.StatusRequestTimeout || e.Status >= 500
+	default:
+		return false
+	}
+}
+
+// KindOf takes an error and returns the Kind of the Error in it, or blank if there isn't one
+func KindOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	return ""
+}
+
+// Retryable takes an error and returns true if it is an Error that could work when tried again later
+func Retryable(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Retryable()
+}
+
+// NewClient takes a timeout for whole requests and returns an http.Client that gives up on them after it, with
+// shorter timeouts for connecting and for the server to start responding. A timeout of 0 or less uses DefaultTimeout
+func NewClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = 5 * time.Second
+	transport.ResponseHeaderTimeout = timeout / 2
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Classify takes the response and error from making a request, and returns nil if it succeeded with a 2xx status,
+// otherwise an Error of the right Kind. The response body is closed if it failed
+func Classify(resp *http.Response, err error) error {
+	if err != nil {
+		return &Error{Kind: Network, Err: err}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	resp.Body.Close()
+
+	// Anything else is a server error, though only timeouts and 5xx are worth retrying
+	e := &Error{Kind: Server, Status: resp.StatusCode, Err: errors.New(http.StatusText(resp.StatusCode))}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Kind = Auth
+	case http.StatusTooManyRequests:
+		e.Kind = RateLimit
+	}
+
+	return e
+}
+
+// Backoff works out how long to wait after each failure in a row, doubling from Base up to Max, with full jitter so
+// many clients failing together don't all retry together: the wait is a random time up to the doubled delay
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+	// Rand returns a random number in [0, 1), and is rand.Float64 if nil
+	Rand func() float64
+}
+
+// Delay takes how many failures there have been in a row, starting at 1, and returns how long to wait before trying again
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	ceiling := float64(b.Max)
+	if failures < 63 {
+		ceiling = math.Min(ceiling, float64(b.Base)*math.Pow(2, float64(failures-1)))
+	}
+
+	random := b.Rand
+	if random == nil {
+		random = rand.Float64
+	}
+	return time.Duration(random() * ceiling)
+}
@@ -0,0 +1,101 @@
+package fetch
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		status    int
+		kind      string
+		retryable bool
+	}{
+		{status: http.StatusOK, kind: ""},
+		{status: http.StatusNoContent, kind: ""},
+		{status: http.StatusUnauthorized, kind: Auth, retryable: false},
+		{status: http.StatusForbidden, kind: Auth, retryable: false},
+		{status: http.StatusTooManyRequests, kind: RateLimit, retryable: true},
+		{status: http.StatusInternalServerError, kind: Server, retryable: true},
+		{status: http.StatusBadGateway, kind: Server, retryable: true},
+		{status: http.StatusRequestTimeout, kind: Server, retryable: true},
+		{status: http.StatusNotFound, kind: Server, retryable: false},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{StatusCode: test.status, Body: io.NopCloser(strings.NewReader(""))}
+		err := Classify(resp, nil)
+		assert.Equal(t, test.kind, KindOf(err), "status %v", test.status)
+		assert.Equal(t, test.retryable, Retryable(err), "status %v", test.status)
+	}
+
+	err := Classify(nil, errors.New("connection refused"))
+	assert.Equal(t, Network, KindOf(err))
+	assert.True(t, Retryable(err))
+	assert.EqualError(t, err, "network error: connection refused")
+
+	err = Classify(&http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil)
+	assert.EqualError(t, err, "server error: status 503: Service Unavailable")
+
+	assert.Equal(t, "", KindOf(errors.New("something else")))
+	assert.False(t, Retryable(errors.New("something else")))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, (&Error{Kind: Server}).Retryable())
+	assert.False(t, (&Error{Kind: Parse, Err: errors.New("unexpected EOF")}).Retryable())
+	assert.False(t, (&Error{Kind: Auth, Err: errors.New("no token")}).Retryable())
+}
+
+func TestErrorWraps(t *testing.T) {
+	limited := errors.New("rate limited")
+	err := error(&Error{Kind: RateLimit, Status: http.StatusTooManyRequests, Err: limited})
+	assert.ErrorIs(t, err, limited)
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(50 * time.Millisecond).Get(server.URL)
+	assert.Error(t, err)
+	assert.Equal(t, Network, KindOf(Classify(nil, err)))
+
+	assert.Equal(t, DefaultTimeout, NewClient(0).Timeout)
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: time.Minute, Rand: func() float64 { return 0.999999 }}
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 0},
+		{failures: 1, expected: time.Second},
+		{failures: 2, expected: 2 * time.Second},
+		{failures: 4, expected: 8 * time.Second},
+		{failures: 7, expected: time.Minute},
+		{failures: 100, expected: time.Minute},
+	}
+
+	for _, test := range tests {
+		assert.InDelta(t, float64(test.expected), float64(b.Delay(test.failures)), float64(time.Millisecond), "%v failures", test.failures)
+	}
+
+	// Jitter spreads the wait between nothing and the doubled delay
+	b.Rand = func() float64 { return 0.5 }
+	assert.Equal(t, 4*time.Second, b.Delay(4))
+	b.Rand = nil
+	for i := 0; i < 20; i++ {
+		assert.LessOrEqual(t, b.Delay(3), 4*time.Second)
+	}
+}
@@ -6,6 +6,7 @@ package formatters
 import (
 	"fmt"
 	"math"
+	"planespotter/helpers/i18n"
 	"strings"
 )
 
@@ -42,9 +43,7 @@ func FormatBaroAltitude(baroAltitude interface{}) string {
 		return "N/A"
 	}
 
-	ba *= 3.28084   // Convert meters to feet
-	baFt := int(ba) // Convert to int
-	return fmt.Sprintf("%v ft", baFt)
+	return FormatAltitude(ba, Feet)
 }
 
 // FormatOnGround takes an interface{} and returns the onground string if the underlying value is a bool
@@ -67,9 +66,7 @@ func FormatVelocity(velocity interface{}) string {
 		return "N/A"
 	}
 
-	v *= 1.94384 // convert m/s to knots
-	vKt := int(v)
-	return fmt.Sprintf("%v kts", vKt)
+	return FormatSpeed(v, Knots)
 }
 
 // FormatTrueTrack takes an interface{} and returns the trueTrack string if the underlying value is a float64
@@ -91,8 +88,35 @@ func KmToLatitude(km int) float64 {
 	return res
 }
 
-// KmToLongitude takes km int and lat float64, and converts it to decimal degrees of longitude
-func KmToLongitude(km int, lat float64) float64 {
-	res := float64(km) / 110.320 * math.Cos(lat)
-	return res
+// KmToLongitude takes a distance in km and a latitude in decimal degrees, and returns how many degrees of longitude
+// the distance covers. Degrees of longitude get shorter towards the poles, so the result grows with latitude
+// It is at most 180, as close to a pole the distance goes all the way round
+func KmToLongitude(km, lat float64) float64 {
+	span := km / (111.320 * math.Cos(lat*math.Pi/180))
+	if math.IsInf(span, 0) || span > 180 {
+		return 180
+	}
+
+	return span
+}
+
+// ParseFloat takes an interface{} and returns the underlying float64 and true if it is a float64
+// Otherwise it returns 0 and false, as the API returns null for values it doesn't know
+func ParseFloat(value interface{}) (float64, bool) {
+	f, ok := value.(float64)
+	if !ok {
+		return 0, false
+	}
+
+	return f, true
+}
+
+// compassPoints are the 8 main points of the compass, clockwise from north
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// FormatCompass takes a bearing in degrees and returns the nearest of the 8 main compass points, e.g. "NE"
+// The points are abbreviated in the current language
+func FormatCompass(bearing float64) string {
+	i := int(math.Round(math.Mod(bearing+360, 360)/45)) % len(compassPoints)
+	return i18n.T("compass." + compassPoints[i])
 }
@@ -63,7 +63,7 @@ func TestFormatBaroAltitude(t *testing.T) {
 	}{
 		{
 			input:    1000.00,
-			expected: "3280 ft",
+			expected: "3,280 ft",
 		},
 		{
 			input:    "1000",
@@ -167,19 +167,89 @@ func TestKmToLatitude(t *testing.T) {
 
 func TestKmToLongitude(t *testing.T) {
 	tests := []struct {
-		inputKm  int
+		inputKm  float64
 		inputLat float64
 		expected float64
 	}{
 		{
 			inputKm:  50,
 			inputLat: 51.509865,
-			expected: 0.14532643217451627,
+			expected: 0.7217,
+		},
+		{
+			inputKm:  111.32,
+			inputLat: 0,
+			expected: 1,
+		},
+		{
+			inputKm:  50,
+			inputLat: -60,
+			expected: 0.8983,
+		},
+		{
+			inputKm:  20,
+			inputLat: 90,
+			expected: 180,
+		},
+		{
+			inputKm:  500,
+			inputLat: -89.9,
+			expected: 180,
 		},
 	}
 
 	for _, test := range tests {
 		res := KmToLongitude(test.inputKm, test.inputLat)
+		assert.InDelta(t, test.expected, res, 0.0001)
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		input      interface{}
+		expected   float64
+		expectedOk bool
+	}{
+		{
+			input:      51.4775,
+			expected:   51.4775,
+			expectedOk: true,
+		},
+		{
+			input:      nil,
+			expected:   0,
+			expectedOk: false,
+		},
+		{
+			input:      "51.4775",
+			expected:   0,
+			expectedOk: false,
+		},
+	}
+
+	for _, test := range tests {
+		res, ok := ParseFloat(test.input)
 		assert.Equal(t, test.expected, res)
+		assert.Equal(t, test.expectedOk, ok)
+	}
+}
+
+func TestFormatCompass(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{input: 0, expected: "N"},
+		{input: 22, expected: "N"},
+		{input: 23, expected: "NE"},
+		{input: 180, expected: "S"},
+		{input: 300, expected: "NW"},
+		{input: 350, expected: "N"},
+		{input: -90, expected: "W"},
+	}
+
+	for _, test := range tests {
+		res := FormatCompass(test.input)
+		assert.Equal(t, test.expected, res, "FormatCompass(%v)", test.input)
 	}
 }
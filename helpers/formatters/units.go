@@ -0,0 +1,160 @@
+package formatters
+
+import (
+	"fmt"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"strings"
+)
+
+const Feet = "ft"
+const Metres = "m"
+const FlightLevel = "FL"
+
+// AltitudeUnits lists the units altitudes can be shown in
+var AltitudeUnits = []string{Feet, Metres, FlightLevel}
+
+const Knots = "kts"
+const KmPerHour = "km/h"
+const Mph = "mph"
+const MetresPerSecond = "m/s"
+
+// SpeedUnits lists the units speeds can be shown in
+var SpeedUnits = []string{Knots, KmPerHour, Mph, MetresPerSecond}
+
+const Km = "km"
+const NauticalMiles = "nm"
+const Miles = "mi"
+
+// DistanceUnits lists the units distances can be shown in
+var DistanceUnits = []string{Km, NauticalMiles, Miles}
+
+const FeetPerMinute = "ft/min"
+
+// VerticalRateUnits lists the units vertical rates can be shown in
+var VerticalRateUnits = []string{FeetPerMinute, MetresPerSecond}
+
+// perBase is how many of each unit there are in one metre for altitudes, one m/s for speeds and vertical rates,
+// and one km for distances, which are the units the API and the settings use
+var perBase = map[string]float64{
+	Feet:            3.28084,
+	Metres:          1,
+	FlightLevel:     0.0328084,
+	Knots:           1.94384,
+	KmPerHour:       3.6,
+	Mph:             2.23694,
+	MetresPerSecond: 1,
+	Km:              1,
+	NauticalMiles:   0.539957,
+	Miles:           0.621371,
+	FeetPerMinute:   196.850,
+}
+
+// ToUnit takes a value in metres, m/s or km and a unit, and returns the value in that unit
+// An unknown unit leaves the value unchanged
+func ToUnit(value float64, unit string) float64 {
+	factor, ok := perBase[unit]
+	if !ok {
+		return value
+	}
+
+	return value * factor
+}
+
+// FromUnit takes a value in a unit, and returns it in metres, m/s or km
+// An unknown unit leaves the value unchanged
+func FromUnit(value float64, unit string) float64 {
+	factor, ok := perBase[unit]
+	if !ok {
+		return value
+	}
+
+	return value / factor
+}
+
+// AltitudeUnit takes the configured altitude unit and returns it, or feet if it is blank or unknown
+func AltitudeUnit(unit string) string {
+	return unitOr(unit, AltitudeUnits)
+}
+
+// SpeedUnit takes the configured speed unit and returns it, or knots if it is blank or unknown
+func SpeedUnit(unit string) string {
+	return unitOr(unit, SpeedUnits)
+}
+
+// DistanceUnit takes the configured distance unit and returns it, or km if it is blank or unknown
+func DistanceUnit(unit string) string {
+	return unitOr(unit, DistanceUnits)
+}
+
+// VerticalRateUnit takes the configured vertical rate unit and returns it, or ft/min if it is blank or unknown
+func VerticalRateUnit(unit string) string {
+	return unitOr(unit, VerticalRateUnits)
+}
+
+// FormatAltitude takes an altitude in metres and a unit, and returns it rounded down with the unit, e.g. "3,500 ft"
+// Flight levels are hundreds of feet written as "FL035"
+func FormatAltitude(metres float64, unit string) string {
+	unit = AltitudeUnit(unit)
+	value := int(ToUnit(metres, unit))
+	if unit == FlightLevel {
+		return fmt.Sprintf("FL%03d", value)
+	}
+
+	return fmt.Sprintf("%v %v", i18n.Number(float64(value), 0), unit)
+}
+
+// FormatSpeed takes a speed in m/s and a unit, and returns it rounded down with the unit, e.g. "180 kts"
+func FormatSpeed(ms float64, unit string) string {
+	unit = SpeedUnit(unit)
+	return fmt.Sprintf("%v %v", i18n.Number(float64(int(ToUnit(ms, unit))), 0), unit)
+}
+
+// FormatDistance takes a distance in km and a unit, and returns it to one decimal place with the unit, e.g. "1.9 km"
+func FormatDistance(km float64, unit string) string {
+	unit = DistanceUnit(unit)
+	return fmt.Sprintf("%v %v", i18n.Number(ToUnit(km, unit), 1), unit)
+}
+
+// FormatVerticalRate takes a vertical rate in m/s and a unit, and returns it with a sign and the unit,
+// e.g. "-690 ft/min" or "+3.5 m/s"
+func FormatVerticalRate(ms float64, unit string) string {
+	unit = VerticalRateUnit(unit)
+	value := i18n.Number(ms, 1)
+	if unit != MetresPerSecond {
+		value = i18n.Number(float64(int(ToUnit(ms, unit))), 0)
+	}
+
+	if !strings.HasPrefix(value, "-") {
+		value = "+" + value
+	}
+	return fmt.Sprintf("%v %v", value, unit)
+}
+
+// ApplyUnits takes a PlaneInfo and the Units to show, and returns it with its altitude, velocity and vertical rate
+// formatted from its StateVector in those units, or "N/A" where the API didn't report them
+func ApplyUnits(p types.PlaneInfo, units types.Units) types.PlaneInfo {
+	p.Baro_Altitude, p.Velocity, p.Vertical_Rate = "N/A", "N/A", "N/A"
+	if p.State.Has_Baro_Altitude {
+		p.Baro_Altitude = FormatAltitude(p.State.Baro_Altitude, units.Altitude)
+	}
+	if p.State.Has_Velocity {
+		p.Velocity = FormatSpeed(p.State.Velocity, units.Speed)
+	}
+	if p.State.Has_Vertical_Rate {
+		p.Vertical_Rate = FormatVerticalRate(p.State.Vertical_Rate, units.VerticalRate)
+	}
+
+	return p
+}
+
+// unitOr takes a unit and the units allowed, and returns the unit if it is allowed, otherwise the first allowed unit
+func unitOr(unit string, allowed []string) string {
+	for _, u := range allowed {
+		if u == unit {
+			return unit
+		}
+	}
+
+	return allowed[0]
+}
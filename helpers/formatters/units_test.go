@@ -0,0 +1,68 @@
+package formatters
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		format   func(float64, string) string
+		value    float64
+		unit     string
+		expected string
+	}{
+		{format: FormatAltitude, value: 1067, unit: "", expected: "3,500 ft"},
+		{format: FormatAltitude, value: 1067, unit: Metres, expected: "1,067 m"},
+		{format: FormatAltitude, value: 1067, unit: FlightLevel, expected: "FL035"},
+		{format: FormatAltitude, value: 11278, unit: FlightLevel, expected: "FL370"},
+		{format: FormatAltitude, value: 1067, unit: "furlongs", expected: "3,500 ft"},
+		{format: FormatSpeed, value: 92.7, unit: "", expected: "180 kts"},
+		{format: FormatSpeed, value: 92.7, unit: KmPerHour, expected: "333 km/h"},
+		{format: FormatSpeed, value: 92.7, unit: Mph, expected: "207 mph"},
+		{format: FormatSpeed, value: 92.7, unit: MetresPerSecond, expected: "92 m/s"},
+		{format: FormatDistance, value: 1.85, unit: "", expected: "1.9 km"},
+		{format: FormatDistance, value: 1.852, unit: NauticalMiles, expected: "1.0 nm"},
+		{format: FormatDistance, value: 1.609, unit: Miles, expected: "1.0 mi"},
+		{format: FormatVerticalRate, value: -3.5, unit: "", expected: "-688 ft/min"},
+		{format: FormatVerticalRate, value: 5.1, unit: FeetPerMinute, expected: "+1,003 ft/min"},
+		{format: FormatVerticalRate, value: -3.5, unit: MetresPerSecond, expected: "-3.5 m/s"},
+	}
+
+	for _, test := range tests {
+		res := test.format(test.value, test.unit)
+		assert.Equal(t, test.expected, res, "format(%v, %q)", test.value, test.unit)
+	}
+}
+
+func TestToUnit(t *testing.T) {
+	assert.InDelta(t, 10.8, ToUnit(20, NauticalMiles), 0.01)
+	assert.InDelta(t, 20, FromUnit(ToUnit(20, NauticalMiles), NauticalMiles), 0.0001)
+	assert.InDelta(t, 1000, FromUnit(ToUnit(1000, FlightLevel), FlightLevel), 0.0001)
+	assert.Equal(t, 5.0, ToUnit(5, "unknown"))
+	assert.Equal(t, 5.0, FromUnit(5, ""))
+}
+
+func TestApplyUnits(t *testing.T) {
+	p := types.PlaneInfo{Callsign: "BAW12", State: types.StateVector{
+		Has_Baro_Altitude: true, Baro_Altitude: 1067, Has_Velocity: true, Velocity: 92.7, Has_Vertical_Rate: true, Vertical_Rate: -3.5,
+	}}
+
+	res := ApplyUnits(p, types.Units{})
+	assert.Equal(t, "3,500 ft", res.Baro_Altitude)
+	assert.Equal(t, "180 kts", res.Velocity)
+	assert.Equal(t, "-688 ft/min", res.Vertical_Rate)
+
+	res = ApplyUnits(p, types.Units{Altitude: Metres, Speed: KmPerHour, VerticalRate: MetresPerSecond})
+	assert.Equal(t, "1,067 m", res.Baro_Altitude)
+	assert.Equal(t, "333 km/h", res.Velocity)
+	assert.Equal(t, "-3.5 m/s", res.Vertical_Rate)
+
+	// Values the API didn't report stay N/A in any units
+	res = ApplyUnits(types.PlaneInfo{Callsign: "NODATA1"}, types.Units{Altitude: FlightLevel})
+	assert.Equal(t, "N/A", res.Baro_Altitude)
+	assert.Equal(t, "N/A", res.Velocity)
+	assert.Equal(t, "N/A", res.Vertical_Rate)
+}
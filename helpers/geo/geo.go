@@ -0,0 +1,108 @@
+// Package geo provides great-circle distance and bearing calculations between positions,
+// tests for whether a position is inside a polygon or near a line, and where to look for a plane from the observer.
+
+package geo
+
+import (
+	"math"
+	"planespotter/helpers/types"
+)
+
+const EarthRadiusKm = 6371.0088
+
+// DistanceKm takes two Positions and returns the great-circle distance between them in km, using the haversine formula
+func DistanceKm(a, b types.Position) float64 {
+	lat1, lat2 := toRadians(a.Latitude), toRadians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := toRadians(b.Longitude - a.Longitude)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing takes two Positions and returns the initial great-circle bearing from a to b in degrees, 0..360 clockwise from north
+func Bearing(a, b types.Position) float64 {
+	lat1, lat2 := toRadians(a.Latitude), toRadians(b.Latitude)
+	dLon := toRadians(b.Longitude - a.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination takes a starting Position, a bearing in degrees and a distance in km, and returns the Position reached
+// by following the great circle from the start on that initial bearing for that distance
+func Destination(start types.Position, bearing, km float64) types.Position {
+	lat1, lon1 := toRadians(start.Latitude), toRadians(start.Longitude)
+	angle := km / EarthRadiusKm
+	theta := toRadians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat1), math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+
+	return types.Position{Latitude: toDegrees(lat2), Longitude: math.Mod(toDegrees(lon2)+540, 360) - 180}
+}
+
+// InPolygon takes a Position and the vertices of a polygon, and returns true if the position is inside the polygon
+// The polygon is closed automatically, and treated as flat in latitude/longitude which is fine for spot-sized areas
+func InPolygon(pos types.Position, polygon []types.Position) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > pos.Latitude) != (b.Latitude > pos.Latitude) {
+			crossLon := a.Longitude + (pos.Latitude-a.Latitude)/(b.Latitude-a.Latitude)*(b.Longitude-a.Longitude)
+			if pos.Longitude < crossLon {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+// DistanceToLineKm takes a Position and the points of a line, and returns the shortest distance in km from the
+// position to any segment of the line. Returns +Inf if the line has no points
+func DistanceToLineKm(pos types.Position, line []types.Position) float64 {
+	if len(line) == 1 {
+		return DistanceKm(pos, line[0])
+	}
+
+	shortest := math.Inf(1)
+	for i := 1; i < len(line); i++ {
+		shortest = math.Min(shortest, distanceToSegmentKm(pos, line[i-1], line[i]))
+	}
+
+	return shortest
+}
+
+// distanceToSegmentKm takes a Position and the two ends of a segment, and returns the shortest distance in km
+// from the position to the segment, working on a flat projection around the position
+func distanceToSegmentKm(pos, a, b types.Position) float64 {
+	ax, ay := localKm(pos, a)
+	bx, by := localKm(pos, b)
+	dx, dy := bx-ax, by-ay
+
+	// How far along the segment the closest point is, from 0 at a to 1 at b
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// localKm takes an origin and a Position, and returns the position's km east and north of the origin
+func localKm(origin, pos types.Position) (float64, float64) {
+	dLon := math.Mod(pos.Longitude-origin.Longitude+540, 360) - 180
+	x := toRadians(dLon) * EarthRadiusKm * math.Cos(toRadians(origin.Latitude))
+	y := toRadians(pos.Latitude-origin.Latitude) * EarthRadiusKm
+	return x, y
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
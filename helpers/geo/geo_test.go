@@ -0,0 +1,171 @@
+package geo
+
+import (
+	"math"
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var heathrow = types.Position{Latitude: 51.4775, Longitude: -0.4614}
+var gatwick = types.Position{Latitude: 51.1481, Longitude: -0.1903}
+var jfk = types.Position{Latitude: 40.6413, Longitude: -73.7781}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		a        types.Position
+		b        types.Position
+		expected float64
+	}{
+		{
+			a:        heathrow,
+			b:        heathrow,
+			expected: 0,
+		},
+		{
+			a:        heathrow,
+			b:        gatwick,
+			expected: 41.2,
+		},
+		{
+			a:        heathrow,
+			b:        jfk,
+			expected: 5539,
+		},
+	}
+
+	for _, test := range tests {
+		res := DistanceKm(test.a, test.b)
+		assert.InDelta(t, test.expected, res, test.expected*0.005+0.001, "DistanceKm(%+v, %+v)", test.a, test.b)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		a        types.Position
+		b        types.Position
+		expected float64
+	}{
+		{
+			a:        types.Position{Latitude: 0, Longitude: 0},
+			b:        types.Position{Latitude: 1, Longitude: 0},
+			expected: 0,
+		},
+		{
+			a:        types.Position{Latitude: 0, Longitude: 0},
+			b:        types.Position{Latitude: 0, Longitude: 1},
+			expected: 90,
+		},
+		{
+			a:        types.Position{Latitude: 0, Longitude: 0},
+			b:        types.Position{Latitude: 0, Longitude: -1},
+			expected: 270,
+		},
+		{
+			a:        heathrow,
+			b:        gatwick,
+			expected: 152.7,
+		},
+	}
+
+	for _, test := range tests {
+		res := Bearing(test.a, test.b)
+		assert.InDelta(t, test.expected, res, 0.5, "Bearing(%+v, %+v)", test.a, test.b)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	res := Destination(heathrow, Bearing(heathrow, gatwick), DistanceKm(heathrow, gatwick))
+	assert.InDelta(t, gatwick.Latitude, res.Latitude, 0.0001)
+	assert.InDelta(t, gatwick.Longitude, res.Longitude, 0.0001)
+
+	res = Destination(types.Position{Latitude: 0, Longitude: 179.5}, 90, 111.195)
+	assert.InDelta(t, 0, res.Latitude, 0.0001)
+	assert.InDelta(t, -179.5, res.Longitude, 0.0001)
+}
+
+func TestInPolygon(t *testing.T) {
+	// An L shape, so the notch is outside
+	polygon := []types.Position{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 2, Longitude: 0},
+		{Latitude: 2, Longitude: 1},
+		{Latitude: 1, Longitude: 1},
+		{Latitude: 1, Longitude: 2},
+		{Latitude: 0, Longitude: 2},
+	}
+
+	tests := []struct {
+		pos      types.Position
+		expected bool
+	}{
+		{pos: types.Position{Latitude: 0.5, Longitude: 0.5}, expected: true},
+		{pos: types.Position{Latitude: 1.5, Longitude: 0.5}, expected: true},
+		{pos: types.Position{Latitude: 0.5, Longitude: 1.5}, expected: true},
+		{pos: types.Position{Latitude: 1.5, Longitude: 1.5}, expected: false},
+		{pos: types.Position{Latitude: -0.5, Longitude: 0.5}, expected: false},
+		{pos: types.Position{Latitude: 0.5, Longitude: 2.5}, expected: false},
+	}
+
+	for _, test := range tests {
+		res := InPolygon(test.pos, polygon)
+		assert.Equal(t, test.expected, res, "InPolygon(%+v)", test.pos)
+	}
+
+	assert.False(t, InPolygon(types.Position{}, nil))
+}
+
+func TestDistanceToLineKm(t *testing.T) {
+	// Runway 27L extended centreline, heading west from Heathrow
+	line := []types.Position{{Latitude: 51.4650, Longitude: -0.4340}, {Latitude: 51.4650, Longitude: -0.1}}
+
+	tests := []struct {
+		pos      types.Position
+		expected float64
+	}{
+		{pos: types.Position{Latitude: 51.4650, Longitude: -0.3}, expected: 0},
+		{pos: types.Position{Latitude: 51.4740, Longitude: -0.3}, expected: 1.0},
+		{pos: types.Position{Latitude: 51.4650, Longitude: -0.0}, expected: 6.93},
+		{pos: types.Position{Latitude: 51.4650, Longitude: -0.4340}, expected: 0},
+	}
+
+	for _, test := range tests {
+		res := DistanceToLineKm(test.pos, line)
+		assert.InDelta(t, test.expected, res, 0.01, "DistanceToLineKm(%+v)", test.pos)
+	}
+
+	assert.InDelta(t, 41.2, DistanceToLineKm(heathrow, []types.Position{gatwick}), 0.1)
+	assert.True(t, math.IsInf(DistanceToLineKm(heathrow, nil), 1))
+}
+
+func TestLook(t *testing.T) {
+	observer := types.Position{Latitude: 51.5, Longitude: -0.1, ElevationM: 50}
+
+	tests := []struct {
+		state     types.StateVector
+		azimuth   float64
+		elevation float64
+		slant     float64
+	}{
+		// 10km north at 10km up is 45 degrees, less a little for the curve of the Earth
+		{state: types.StateVector{Has_Position: true, Latitude: 51.5899, Longitude: -0.1, Has_Baro_Altitude: true, Baro_Altitude: 10050}, azimuth: 0, elevation: 44.96, slant: 14.14},
+		// Far off to the north east is below the horizon at ground level
+		{state: types.StateVector{Has_Position: true, Latitude: 52.5, Longitude: 1.5, Has_Geo_Altitude: true, Geo_Altitude: 50}, azimuth: 44.0, elevation: -0.8, slant: 156.4},
+	}
+
+	for _, test := range tests {
+		res, ok := Look(observer, test.state)
+		assert.True(t, ok)
+		assert.InDelta(t, test.azimuth, res.AzimuthDeg, 0.1, "Look(%+v) azimuth", test.state)
+		assert.InDelta(t, test.elevation, res.ElevationDeg, 0.1, "Look(%+v) elevation", test.state)
+		assert.InDelta(t, test.slant, res.SlantRangeKm, 1, "Look(%+v) slant range", test.state)
+	}
+
+	overhead, _ := Look(observer, types.StateVector{Has_Position: true, Latitude: 51.5, Longitude: -0.1, Has_Geo_Altitude: true, Geo_Altitude: 1050})
+	assert.InDelta(t, 90, overhead.ElevationDeg, 0.001)
+	assert.InDelta(t, 1, overhead.SlantRangeKm, 0.001)
+
+	_, ok := Look(observer, types.StateVector{Has_Position: true})
+	assert.False(t, ok)
+}
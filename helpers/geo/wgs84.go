@@ -0,0 +1,52 @@
+package geo
+
+import (
+	"math"
+	"planespotter/helpers/types"
+)
+
+// WGS84 ellipsoid semi-major axis in metres and flattening
+const wgs84A = 6378137.0
+const wgs84F = 1 / 298.257223563
+
+// Look takes the observer's Position and a plane's StateVector, and returns the LookAngles from the observer to
+// the plane on the WGS84 ellipsoid. Uses the geometric altitude where there is one, otherwise the barometric
+// Returns false if the plane has no position or altitude
+func Look(observer types.Position, state types.StateVector) (types.LookAngles, bool) {
+	altitude, hasAltitude := state.Geo_Altitude, state.Has_Geo_Altitude
+	if !hasAltitude {
+		altitude, hasAltitude = state.Baro_Altitude, state.Has_Baro_Altitude
+	}
+	if !state.Has_Position || !hasAltitude {
+		return types.LookAngles{}, false
+	}
+
+	ox, oy, oz := ecef(observer.Latitude, observer.Longitude, observer.ElevationM)
+	px, py, pz := ecef(state.Latitude, state.Longitude, altitude)
+	dx, dy, dz := px-ox, py-oy, pz-oz
+
+	// Rotate the difference into east, north and up at the observer
+	lat, lon := toRadians(observer.Latitude), toRadians(observer.Longitude)
+	east := -math.Sin(lon)*dx + math.Cos(lon)*dy
+	north := -math.Sin(lat)*math.Cos(lon)*dx - math.Sin(lat)*math.Sin(lon)*dy + math.Cos(lat)*dz
+	up := math.Cos(lat)*math.Cos(lon)*dx + math.Cos(lat)*math.Sin(lon)*dy + math.Sin(lat)*dz
+
+	return types.LookAngles{
+		AzimuthDeg:   math.Mod(toDegrees(math.Atan2(east, north))+360, 360),
+		ElevationDeg: toDegrees(math.Atan2(up, math.Hypot(east, north))),
+		SlantRangeKm: math.Sqrt(dx*dx+dy*dy+dz*dz) / 1000,
+	}, true
+}
+
+// ecef takes a latitude and longitude in degrees and a height in metres above the WGS84 ellipsoid, and returns
+// the Earth-centred, Earth-fixed x, y and z coordinates in metres
+func ecef(latDeg, lonDeg, heightM float64) (float64, float64, float64) {
+	lat, lon := toRadians(latDeg), toRadians(lonDeg)
+	e2 := wgs84F * (2 - wgs84F)
+	n := wgs84A / math.Sqrt(1-e2*math.Pow(math.Sin(lat), 2))
+
+	x := (n + heightM) * math.Cos(lat) * math.Cos(lon)
+	y := (n + heightM) * math.Cos(lat) * math.Sin(lon)
+	z := (n*(1-e2) + heightM) * math.Sin(lat)
+	return x, y, z
+}
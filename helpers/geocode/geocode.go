@@ -0,0 +1,339 @@
+// Package geocode finds the coordinates of a town, postcode area or airport from its name or code,
+// using a gazetteer with fuzzy matching for typos, and optionally an online geocoder. The gazetteer built into
+// the binary only covers major towns, UK postcode areas and airports, so a bigger one such as a GeoNames
+// extract can be loaded instead.
+
+package geocode
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const City = "city"
+const Postcode = "postcode"
+const Airport = "airport"
+
+// Kinds lists the kinds of place a gazetteer can hold
+var Kinds = []string{City, Postcode, Airport}
+
+// DefaultLimit is how many places a search returns when no limit is given
+const DefaultLimit = 5
+
+//go:embed gazetteer.csv
+var builtIn []byte
+
+var gazetteerHeader = []string{"name", "kind", "country", "codes", "latitude", "longitude"}
+
+// postcodePattern matches the start of a UK postcode, e.g. "SW1A 1AA" or "M1", capturing its area letters
+var postcodePattern = regexp.MustCompile(`^([A-Z]{1,2})[0-9]`)
+
+// Geocoder is anything that can turn a search for a place into matching Places, best match first
+type Geocoder interface {
+	Search(query string, limit int) ([]types.Place, error)
+}
+
+// Gazetteer is a list of places searched offline
+type Gazetteer struct {
+	places []types.Place
+}
+
+var defaultGazetteer *Gazetteer
+var loadDefault sync.Once
+
+// Default returns the Gazetteer built into the binary
+func Default() *Gazetteer {
+	loadDefault.Do(func() {
+		g, err := ReadGazetteer(bytes.NewReader(builtIn))
+		if err != nil {
+			// The built in gazetteer is part of the binary, so one that can't be read is a bug
+			panic(err)
+		}
+		defaultGazetteer = g
+	})
+
+	return defaultGazetteer
+}
+
+// ReadGazetteer takes a Reader of gazetteer CSV, with the columns name, kind, country, codes, latitude and longitude,
+// and returns the Gazetteer. Codes are separated by spaces. Returns an error naming the row if one can't be read
+func ReadGazetteer(r io.Reader) (*Gazetteer, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(gazetteerHeader)
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || strings.Join(rows[0], ",") != strings.Join(gazetteerHeader, ",") {
+		return nil, fmt.Errorf("expected a header of %v", strings.Join(gazetteerHeader, ","))
+	}
+
+	g := &Gazetteer{}
+	for i, row := range rows[1:] {
+		lat, latErr := strconv.ParseFloat(row[4], 64)
+		lon, lonErr := strconv.ParseFloat(row[5], 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("row %v: expected latitude and longitude in decimal degrees", i+2)
+		}
+
+		g.places = append(g.places, types.Place{
+			Name:     row[0],
+			Kind:     row[1],
+			Country:  row[2],
+			Codes:    strings.Fields(strings.ToUpper(row[3])),
+			Position: types.Position{Latitude: lat, Longitude: lon},
+		})
+	}
+
+	return g, nil
+}
+
+// geoNamesColumns is how many tab separated columns a GeoNames geoname table has
+const geoNamesColumns = 19
+
+// ReadGeoNames takes a Reader of a GeoNames geoname table, such as cities15000.txt from
+// https://download.geonames.org/export/dump/, and returns its places as a Gazetteer of cities.
+// Returns an error naming the row if one can't be read
+func ReadGeoNames(r io.Reader) (*Gazetteer, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = geoNamesColumns
+
+	g := &Gazetteer{}
+	for row := 1; ; row++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		lat, latErr := strconv.ParseFloat(fields[4], 64)
+		lon, lonErr := strconv.ParseFloat(fields[5], 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("row %v: expected latitude and longitude in decimal degrees", row)
+		}
+
+		g.places = append(g.places, types.Place{
+			Name:     fields[1],
+			Kind:     City,
+			Country:  fields[8],
+			Position: types.Position{Latitude: lat, Longitude: lon},
+		})
+	}
+}
+
+// LoadGazetteer takes the path to a gazetteer, either a CSV file or a GeoNames geoname table ending in .txt,
+// and returns the Gazetteer in it
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".txt") {
+		return ReadGeoNames(f)
+	}
+	return ReadGazetteer(f)
+}
+
+// match is a place found by a search, and how good a match it is, lower being better
+type match struct {
+	place types.Place
+	score int
+}
+
+// Search takes a place name, postcode or ICAO/IATA code and a limit, and returns up to that many matching Places,
+// best match first. Codes and postcode areas must match exactly, while names can have a typo or two in them
+// or just be the start of the name or one of its words. A limit of 0 or less uses DefaultLimit
+func (g *Gazetteer) Search(query string, limit int) ([]types.Place, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	name := normalise(query)
+	if name == "" {
+		return nil, nil
+	}
+	code := strings.ToUpper(strings.Join(strings.Fields(query), ""))
+	area := ""
+	if m := postcodePattern.FindStringSubmatch(code); m != nil {
+		area = m[1]
+	}
+
+	var matches []match
+	for _, p := range g.places {
+		if score, ok := scorePlace(p, name, code, area); ok {
+			matches = append(matches, match{place: p, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	var places []types.Place
+	for i := 0; i < len(matches) && i < limit; i++ {
+		places = append(places, matches[i].place)
+	}
+
+	return places, nil
+}
+
+// scorePlace takes a Place and a normalised name, code and postcode area from a search, and returns how well the
+// place matches, lower being better, and false if it doesn't match at all
+func scorePlace(p types.Place, name, code, area string) (int, bool) {
+	for _, c := range p.Codes {
+		if c == code || (p.Kind == Postcode && c == area) {
+			return 0, true
+		}
+	}
+	if p.Kind == Postcode {
+		// Postcode areas are found by their code, their names are the towns they are named after
+		return 0, false
+	}
+
+	placeName := normalise(p.Name)
+	switch {
+	case placeName == name:
+		return 1, true
+	case strings.Contains(" "+placeName, " "+name) && len(name) >= 3:
+		// The start of the name or of any word in it, so "heathrow" finds London Heathrow Airport
+		return 2, true
+	}
+
+	// Allow about one typo for every four letters, comparing with the start of the name so a typo in
+	// a partly typed name still matches
+	tolerance := len([]rune(name)) / 4
+	if tolerance == 0 {
+		return 0, false
+	}
+	distance := levenshtein(name, placeName)
+	if prefix := []rune(placeName); len(prefix) > len([]rune(name)) {
+		distance = min(distance, levenshtein(name, string(prefix[:len([]rune(name))])))
+	}
+	if distance <= tolerance {
+		return 3 + distance, true
+	}
+
+	return 0, false
+}
+
+// foldAccents replaces accented letters with their unaccented forms, so "Zurich" finds "Zürich"
+var foldAccents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// normalise takes a place name and returns it lower case, without accents, and with punctuation
+// and repeated spaces removed, for comparing names
+func normalise(name string) string {
+	name = foldAccents.Replace(strings.ToLower(name))
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '/' || r == '\'' || r == '.' || r == ',' {
+			return ' '
+		}
+		return r
+	}, name)
+
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// levenshtein takes two strings and returns the number of single letter insertions, deletions and substitutions
+// needed to turn one into the other
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(rb)]
+}
+
+// Describe takes a Place and returns its name with its codes and country, e.g. "London Heathrow Airport (EGLL LHR), GB"
+func Describe(p types.Place) string {
+	description := p.Name
+	if len(p.Codes) > 0 && p.Kind != Postcode {
+		description += " (" + strings.Join(p.Codes, " ") + ")"
+	}
+	if p.Kind == Postcode {
+		description = strings.Join(p.Codes, " ") + " " + description
+	}
+	if p.Country != "" {
+		description += ", " + p.Country
+	}
+
+	return description
+}
+
+// Chain is a list of Geocoders tried in order until one finds something
+type Chain []Geocoder
+
+// Search takes a query and a limit, and returns the Places found by the first Geocoder in the chain to find any
+// Returns an error only if nothing was found and a Geocoder failed
+func (c Chain) Search(query string, limit int) ([]types.Place, error) {
+	var errs []error
+	for _, g := range c {
+		places, err := g.Search(query, limit)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(places) > 0 {
+			return places, nil
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// New takes the Geocoding settings and returns a Geocoder searching the configured gazetteer, or the built in one,
+// then the online geocoder if there is one. Returns an error if the gazetteer file can't be loaded
+func New(settings types.Geocoding) (Geocoder, error) {
+	g := Default()
+	if settings.Gazetteer != "" {
+		var err error
+		if g, err = LoadGazetteer(settings.Gazetteer); err != nil {
+			return nil, fmt.Errorf("loading gazetteer %v: %w", settings.Gazetteer, err)
+		}
+	}
+
+	chain := Chain{g}
+	if settings.Url != "" {
+		chain = append(chain, NewNominatim(settings.Url))
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,156 @@
+package geocode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"planespotter/helpers/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		kind     string
+	}{
+		{query: "London", expected: "London", kind: City},
+		{query: "  new   YORK ", expected: "New York", kind: City},
+		{query: "EGLL", expected: "London Heathrow Airport", kind: Airport},
+		{query: "lhr", expected: "London Heathrow Airport", kind: Airport},
+		{query: "heathrow", expected: "London Heathrow Airport", kind: Airport},
+		{query: "SW1A 1AA", expected: "London South West", kind: Postcode},
+		{query: "m1", expected: "Manchester", kind: Postcode},
+		{query: "Manchster", expected: "Manchester", kind: City},
+		{query: "Edinbrugh", expected: "Edinburgh", kind: City},
+		{query: "zurich", expected: "Zürich", kind: City},
+		{query: "Montreal", expected: "Montréal", kind: City},
+		{query: "newcastle", expected: "Newcastle upon Tyne", kind: City},
+		{query: "Sao Paulo Guarulhos", expected: "São Paulo/Guarulhos International Airport", kind: Airport},
+	}
+
+	for _, test := range tests {
+		res, err := Default().Search(test.query, 0)
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, res, "Search(%q)", test.query) {
+			assert.Equal(t, test.expected, res[0].Name, "Search(%q)", test.query)
+			assert.Equal(t, test.kind, res[0].Kind, "Search(%q)", test.query)
+		}
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	res, err := Default().Search("Paris", 0)
+	assert.NoError(t, err)
+	assert.Len(t, res, 3)
+	assert.Equal(t, "Paris", res[0].Name)
+	assert.Equal(t, types.Position{Latitude: 48.8566, Longitude: 2.3522}, res[0].Position)
+
+	res, _ = Default().Search("London", 2)
+	assert.Len(t, res, 2)
+
+	// Too short to guess at, or nothing like any place
+	for _, query := range []string{"", "  ", "Lo", "Xyzzyville"} {
+		res, err = Default().Search(query, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, res, "Search(%q)", query)
+	}
+}
+
+func TestReadGazetteer(t *testing.T) {
+	g, err := ReadGazetteer(strings.NewReader("name,kind,country,codes,latitude,longitude\nHome Field,airport,GB,egxx xxx,51.5,-0.1\n"))
+	assert.NoError(t, err)
+	res, _ := g.Search("XXX", 0)
+	assert.Equal(t, []types.Place{{Name: "Home Field", Kind: Airport, Country: "GB", Codes: []string{"EGXX", "XXX"}, Position: types.Position{Latitude: 51.5, Longitude: -0.1}}}, res)
+
+	_, err = ReadGazetteer(strings.NewReader("town,lat,lon\nHome,51.5,-0.1\n"))
+	assert.Error(t, err)
+
+	_, err = ReadGazetteer(strings.NewReader("name,kind,country,codes,latitude,longitude\nHome,city,GB,,north,west\n"))
+	assert.EqualError(t, err, "row 2: expected latitude and longitude in decimal degrees")
+}
+
+func TestReadGeoNames(t *testing.T) {
+	row := "2653877\tLittle Snoring\tLittle Snoring\t\t52.8606\t0.9042\tP\tPPL\tGB\t\tENG\tNFK\t\t\t600\t\t30\tEurope/London\t2020-01-01\n"
+	g, err := ReadGeoNames(strings.NewReader(row))
+	assert.NoError(t, err)
+	res, _ := g.Search("Little Snorign", 0)
+	assert.Equal(t, []types.Place{{Name: "Little Snoring", Kind: City, Country: "GB", Position: types.Position{Latitude: 52.8606, Longitude: 0.9042}}}, res)
+
+	_, err = ReadGeoNames(strings.NewReader(row + strings.Replace(row, "52.8606", "north", 1)))
+	assert.EqualError(t, err, "row 2: expected latitude and longitude in decimal degrees")
+
+	_, err = ReadGeoNames(strings.NewReader("name,kind,country,codes,latitude,longitude\n"))
+	assert.Error(t, err)
+}
+
+func TestDescribe(t *testing.T) {
+	heathrow, _ := Default().Search("LHR", 1)
+	assert.Equal(t, "London Heathrow Airport (EGLL LHR), GB", Describe(heathrow[0]))
+	postcode, _ := Default().Search("SW1A", 1)
+	assert.Equal(t, "SW London South West, GB", Describe(postcode[0]))
+	assert.Equal(t, "Little Snoring", Describe(types.Place{Name: "Little Snoring"}))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("york", "york"))
+	assert.Equal(t, 1, levenshtein("manchster", "manchester"))
+	assert.Equal(t, 2, levenshtein("edinbrugh", "edinburgh"))
+	assert.Equal(t, 4, levenshtein("", "oslo"))
+}
+
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Little Snoring", r.URL.Query().Get("q"))
+		assert.Equal(t, "3", r.URL.Query().Get("limit"))
+		assert.Equal(t, "planespotter", r.Header.Get("User-Agent"))
+		w.Write([]byte(`[{"display_name":"Little Snoring, Norfolk, England","type":"village","lat":"52.8606","lon":"0.9042","address":{"country_code":"gb"}},{"display_name":"Broken","lat":"","lon":""}]`))
+	}))
+	defer server.Close()
+
+	res, err := NewNominatim(server.URL).Search("Little Snoring", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Place{{Name: "Little Snoring, Norfolk, England", Kind: City, Country: "GB", Position: types.Position{Latitude: 52.8606, Longitude: 0.9042}}}, res)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+
+	_, err = NewNominatim(failing.URL).Search("Little Snoring", 3)
+	assert.EqualError(t, err, "geocoder returned status 429")
+}
+
+func TestNew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"display_name":"Little Snoring","lat":"52.8606","lon":"0.9042"}]`))
+	}))
+	defer server.Close()
+
+	g, err := New(types.Geocoding{Url: server.URL})
+	assert.NoError(t, err)
+
+	// Found offline without going online, and online when it isn't in the gazetteer
+	res, err := g.Search("Gatwick", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "London Gatwick Airport", res[0].Name)
+	res, err = g.Search("Little Snoring", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Little Snoring", res[0].Name)
+
+	// Offline only, nothing found is not an error
+	g, _ = New(types.Geocoding{})
+	res, err = g.Search("Little Snoring", 1)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = New(types.Geocoding{Gazetteer: "missing.csv"})
+	assert.Error(t, err)
+}
+
+func TestChainErrors(t *testing.T) {
+	_, err := Chain{Default(), NewNominatim("http://127.0.0.1:1")}.Search("Little Snoring", 1)
+	assert.Error(t, err)
+}
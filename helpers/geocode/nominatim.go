@@ -0,0 +1,97 @@
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"planespotter/helpers/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NominatimGeocoder searches an online geocoder speaking the Nominatim search API, such as OpenStreetMap's
+// at https://nominatim.openstreetmap.org/search
+type NominatimGeocoder struct {
+	Url    string
+	Client *http.Client
+}
+
+// nominatimResult is the part of a Nominatim search result that is used. Nominatim returns coordinates as strings
+type nominatimResult struct {
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Address     struct {
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+}
+
+// NewNominatim takes the url of a Nominatim search endpoint and returns a NominatimGeocoder for it
+func NewNominatim(searchUrl string) *NominatimGeocoder {
+	return &NominatimGeocoder{Url: searchUrl, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Search takes a query and a limit, and returns the Places the geocoder finds
+// Returns an error if the request fails or the response can't be read
+func (n *NominatimGeocoder) Search(query string, limit int) ([]types.Place, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	searchUrl, err := url.Parse(n.Url)
+	if err != nil {
+		return nil, err
+	}
+	searchUrl.RawQuery = url.Values{
+		"q":              {query},
+		"format":         {"jsonv2"},
+		"limit":          {strconv.Itoa(limit)},
+		"addressdetails": {"1"},
+	}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, searchUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Nominatim's usage policy asks for an identifying user agent
+	req.Header.Set("User-Agent", "planespotter")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoder returned status %v", resp.StatusCode)
+	}
+
+	var results []nominatimResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("reading geocoder response: %w", err)
+	}
+
+	var places []types.Place
+	for _, r := range results {
+		lat, latErr := strconv.ParseFloat(r.Lat, 64)
+		lon, lonErr := strconv.ParseFloat(r.Lon, 64)
+		if latErr != nil || lonErr != nil {
+			continue
+		}
+
+		kind := City
+		if r.Type == "aerodrome" {
+			kind = Airport
+		}
+		places = append(places, types.Place{
+			Name:     r.DisplayName,
+			Kind:     kind,
+			Country:  strings.ToUpper(r.Address.CountryCode),
+			Position: types.Position{Latitude: lat, Longitude: lon},
+		})
+	}
+
+	return places, nil
+}
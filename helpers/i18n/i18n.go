@@ -0,0 +1,154 @@
+// Package i18n translates the app's text using the message catalogues embedded from the locales folder,
+// and formats numbers, dates and times the way the chosen language writes them.
+
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const English = "en"
+const French = "fr"
+
+// Languages lists the languages there are catalogues for, English first as it is the fallback
+var Languages = []string{English, French}
+
+// Names are each language's name for itself, to show in the language selector
+var Names = map[string]string{English: "English", French: "Français"}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogues = loadCatalogues()
+
+var current = English
+var currentLock sync.RWMutex
+
+// Set takes a language and makes it the one T and the formatting functions use
+// A blank or unknown language is detected from the environment instead
+func Set(language string) {
+	if _, ok := catalogues[language]; !ok {
+		language = Detect(os.Getenv)
+	}
+
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	current = language
+}
+
+// Current returns the language in use
+func Current() string {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+	return current
+}
+
+// Detect takes a function to read environment variables, and returns the language set by LC_ALL, LC_MESSAGES
+// or LANG, in that order, e.g. "fr" for fr_FR.UTF-8. Returns English if none are set to a language there is
+// a catalogue for
+func Detect(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+
+		parts := strings.FieldsFunc(value, func(r rune) bool { return r == '_' || r == '-' || r == '.' || r == '@' })
+		if len(parts) == 0 {
+			continue
+		}
+		if language := strings.ToLower(parts[0]); catalogues[language] != nil {
+			return language
+		}
+		// The first variable set wins, even if it is a language without a catalogue, like C or de_DE
+		return English
+	}
+
+	return English
+}
+
+// T takes a message key and any arguments for it, and returns the message in the current language
+// Messages missing from the current catalogue fall back to English, then to the key itself
+// With arguments the message is used as a fmt format, e.g. T("ui.total_seen", 42)
+func T(key string, args ...interface{}) string {
+	message, ok := catalogues[Current()][key]
+	if !ok {
+		message, ok = catalogues[English][key]
+	}
+	if !ok {
+		message = key
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Number takes a number and how many decimal places to show, and returns it with the current language's
+// decimal and thousands separators, e.g. "18,227.5" in English or "18 227,5" in French
+func Number(value float64, decimals int) string {
+	digits := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(T("format.thousands"))
+		}
+		grouped.WriteRune(digit)
+	}
+	if fraction != "" {
+		grouped.WriteString(T("format.decimal") + fraction)
+	}
+
+	// Only negative if something other than zeros is shown, so -0.04 to one place is "0.0"
+	if value < 0 && strings.Trim(digits, "0.") != "" {
+		return "-" + grouped.String()
+	}
+	return grouped.String()
+}
+
+// Date takes a time and returns its date written the current language's way, e.g. "19 Oct 2026" or "19/10/2026"
+func Date(t time.Time) string {
+	return t.Format(T("format.date"))
+}
+
+// Clock takes a time and returns its time of day written the current language's way, e.g. "14:30" or "14 h 30"
+func Clock(t time.Time) string {
+	return t.Format(T("format.clock"))
+}
+
+// loadCatalogues reads every embedded catalogue, and returns their messages by language and key
+// The catalogues are part of the binary, so one that can't be read is a bug and panics
+func loadCatalogues() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := map[string]map[string]string{}
+	for _, f := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("locales/%v: %v", f.Name(), err))
+		}
+		loaded[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+
+	return loaded
+}
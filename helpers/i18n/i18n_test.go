@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCataloguesComplete(t *testing.T) {
+	for _, language := range Languages {
+		assert.Contains(t, catalogues, language)
+		assert.Contains(t, Names, language)
+		for key := range catalogues[English] {
+			assert.Contains(t, catalogues[language], key, "%v catalogue is missing %v", language, key)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		expected string
+	}{
+		{env: map[string]string{}, expected: English},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8"}, expected: French},
+		{env: map[string]string{"LANG": "fr"}, expected: French},
+		{env: map[string]string{"LANG": "FR-ca"}, expected: French},
+		{env: map[string]string{"LANG": "de_DE.UTF-8"}, expected: English},
+		{env: map[string]string{"LANG": "fr_FR.UTF-8", "LC_MESSAGES": "en_GB.UTF-8"}, expected: English},
+		{env: map[string]string{"LANG": "en_GB.UTF-8", "LC_ALL": "fr_BE@euro"}, expected: French},
+		{env: map[string]string{"LC_ALL": "C", "LANG": "fr_FR.UTF-8"}, expected: English},
+		{env: map[string]string{"LC_ALL": ".", "LANG": "fr_FR.UTF-8"}, expected: French},
+	}
+
+	for _, test := range tests {
+		res := Detect(func(name string) string { return test.env[name] })
+		assert.Equal(t, test.expected, res, "Detect(%v)", test.env)
+	}
+}
+
+func TestT(t *testing.T) {
+	defer Set(English)
+
+	Set(English)
+	assert.Equal(t, "Plane Spotted!", T("notify.spotted_title"))
+	assert.Equal(t, "Total seen: 42", T("ui.total_seen", 42))
+	assert.Equal(t, "no.such.key", T("no.such.key"))
+
+	Set(French)
+	assert.Equal(t, French, Current())
+	assert.Equal(t, "Avion repéré !", T("notify.spotted_title"))
+	assert.Equal(t, "Total vu : 42", T("ui.total_seen", 42))
+
+	// Messages missing from the French catalogue fall back to English
+	catalogues[English]["test.only_english"] = "English only"
+	defer delete(catalogues[English], "test.only_english")
+	assert.Equal(t, "English only", T("test.only_english"))
+}
+
+func TestNumber(t *testing.T) {
+	defer Set(English)
+
+	tests := []struct {
+		language string
+		value    float64
+		decimals int
+		expected string
+	}{
+		{language: English, value: 18227, decimals: 0, expected: "18,227"},
+		{language: English, value: 1234567.891, decimals: 2, expected: "1,234,567.89"},
+		{language: English, value: 999, decimals: 1, expected: "999.0"},
+		{language: English, value: -3.5, decimals: 1, expected: "-3.5"},
+		{language: English, value: -0.04, decimals: 1, expected: "0.0"},
+		{language: English, value: -1500, decimals: 0, expected: "-1,500"},
+		{language: French, value: 18227.5, decimals: 1, expected: "18\u00a0227,5"},
+		{language: French, value: 1.9, decimals: 1, expected: "1,9"},
+	}
+
+	for _, test := range tests {
+		Set(test.language)
+		res := Number(test.value, test.decimals)
+		assert.Equal(t, test.expected, res, "Number(%v, %v) in %v", test.value, test.decimals, test.language)
+	}
+}
+
+func TestDateAndClock(t *testing.T) {
+	defer Set(English)
+	when := time.Date(2026, 10, 19, 14, 30, 0, 0, time.UTC)
+
+	Set(English)
+	assert.Equal(t, "19 Oct 2026", Date(when))
+	assert.Equal(t, "14:30", Clock(when))
+
+	Set(French)
+	assert.Equal(t, "19/10/2026", Date(when))
+	assert.Equal(t, "14 h 30", Clock(when))
+}
+
+func TestSetUnknown(t *testing.T) {
+	defer Set(English)
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+
+	Set("xx")
+	assert.Equal(t, French, Current())
+
+	Set("")
+	assert.Equal(t, French, Current())
+}
@@ -0,0 +1,55 @@
+package notifiers
+
+import (
+	"fmt"
+	"log"
+	"planespotter/helpers/types"
+	"sync"
+)
+
+// QueueSize is how many alerts can wait for a notifier sending in the background before more are dropped
+const QueueSize = 100
+
+// Background sends alerts to a Notifier one at a time from its own goroutine, so a slow or retrying notifier
+// doesn't hold up spotting. Failures are logged, as they happen after Notify has returned
+type Background struct {
+	Name     string
+	Notifier Notifier
+	queue    chan types.Alert
+	close    sync.Once
+	done     chan struct{}
+}
+
+// NewBackground takes a notifier's name and the Notifier, and returns a Background sending to it
+func NewBackground(name string, n Notifier) *Background {
+	b := &Background{Name: name, Notifier: n, queue: make(chan types.Alert, QueueSize), done: make(chan struct{})}
+	go b.run()
+
+	return b
+}
+
+// Notify queues the alert to be sent. Returns an error only if the queue is full and the alert was dropped
+func (b *Background) Notify(alert types.Alert) error {
+	select {
+	case b.queue <- alert:
+		return nil
+	default:
+		return fmt.Errorf("%v alerts already waiting, dropped %q", QueueSize, alert.Title)
+	}
+}
+
+// Close stops taking alerts, and returns once those already queued have been sent
+func (b *Background) Close() {
+	b.close.Do(func() { close(b.queue) })
+	<-b.done
+}
+
+// run sends each queued alert until the queue is closed
+func (b *Background) run() {
+	defer close(b.done)
+	for alert := range b.queue {
+		if err := b.Notifier.Notify(alert); err != nil {
+			log.Printf("Error sending notification to %v: %v", b.Name, err)
+		}
+	}
+}
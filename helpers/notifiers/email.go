@@ -0,0 +1,51 @@
+package notifiers
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"planespotter/helpers/types"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends each alert as an email through an SMTP server, given as host:port
+// The server is logged in to with Username and Password if there is a username
+type EmailNotifier struct {
+	Server   string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify emails the alert
+func (n EmailNotifier) Notify(alert types.Alert) error {
+	var auth smtp.Auth
+	if n.Username != "" {
+		host, _, err := net.SplitHostPort(n.Server)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	return smtp.SendMail(n.Server, auth, n.From, n.To, emailMessage(n.From, n.To, alert))
+}
+
+// emailMessage takes the from and to addresses and an Alert, and returns the email to send with its headers
+func emailMessage(from string, to []string, alert types.Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %v\r\n", from)
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %v\r\n", alert.Title)
+	fmt.Fprintf(&b, "Date: %v\r\n", alert.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	for _, line := range strings.Split(alert.Message, "\n") {
+		b.WriteString(strings.TrimSpace(line) + "\r\n")
+	}
+
+	return []byte(b.String())
+}
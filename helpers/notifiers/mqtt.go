@@ -0,0 +1,110 @@
+package notifiers
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"planespotter/helpers/types"
+	"time"
+)
+
+// MqttNotifier publishes each alert as JSON to a topic on an MQTT broker, given as host:port
+// It connects for each alert with MQTT 3.1.1 and publishes at QoS 0, which is all a few alerts a minute need
+type MqttNotifier struct {
+	Broker   string
+	Topic    string
+	Username string
+	Password string
+}
+
+// MQTT control packet types, already shifted into the high nibble of the first byte
+const mqttConnect = 0x10
+const mqttConnack = 0x20
+const mqttPublish = 0x30
+const mqttDisconnect = 0xe0
+
+// Notify publishes the alert to the topic
+// Returns an error if the broker can't be reached or refuses the connection
+func (n MqttNotifier) Notify(alert types.Alert) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", n.Broker, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := conn.Write(mqttConnectPacket(n.Username, n.Password)); err != nil {
+		return err
+	}
+
+	connack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		return fmt.Errorf("reading connack: %w", err)
+	}
+	if connack[0] != mqttConnack || connack[3] != 0 {
+		return fmt.Errorf("broker refused connection with code %v", connack[3])
+	}
+
+	if _, err := conn.Write(mqttPublishPacket(n.Topic, payload)); err != nil {
+		return err
+	}
+	_, err = conn.Write([]byte{mqttDisconnect, 0})
+	return err
+}
+
+// mqttConnectPacket takes a username and password, which can be blank, and returns a CONNECT packet with a clean
+// session and a client id unique to this process
+func mqttConnectPacket(username, password string) []byte {
+	flags := byte(0x02)
+	payload := mqttString(fmt.Sprintf("planespotter-%v", time.Now().UnixNano()))
+	if username != "" {
+		flags |= 0x80
+		payload = append(payload, mqttString(username)...)
+		if password != "" {
+			flags |= 0x40
+			payload = append(payload, mqttString(password)...)
+		}
+	}
+
+	// Protocol name and level 4 for 3.1.1, the flags and a 60 second keep alive
+	header := append(mqttString("MQTT"), 4, flags, 0, 60)
+	return mqttPacket(mqttConnect, append(header, payload...))
+}
+
+// mqttPublishPacket takes a topic and payload, and returns a QoS 0 PUBLISH packet
+func mqttPublishPacket(topic string, payload []byte) []byte {
+	return mqttPacket(mqttPublish, append(mqttString(topic), payload...))
+}
+
+// mqttPacket takes a packet type and the rest of the packet, and returns it with its fixed header
+func mqttPacket(packetType byte, body []byte) []byte {
+	packet := []byte{packetType}
+
+	// The remaining length is 7 bits a byte, with the top bit set when there's another byte to come
+	length := len(body)
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(packet, body...)
+}
+
+// mqttString takes a string and returns it length prefixed, as MQTT encodes strings
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
@@ -0,0 +1,203 @@
+// Package notifiers sends alerts to the user through one or more backends: desktop notifications, a log,
+// webhooks, email, MQTT or a command, with each kind of alert routed to its own choice of notifiers.
+
+package notifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"planespotter/helpers/types"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
+)
+
+// Kinds of alert, which are routed separately
+const Spotted = "spotted"
+const Approaching = "approaching"
+const Summary = "summary"
+
+// Kinds lists the kinds of alert that can be routed
+var Kinds = []string{Spotted, Approaching, Summary}
+
+// Types of notifier
+const Desktop = "desktop"
+const Log = "log"
+const Webhook = "webhook"
+const Email = "email"
+const Mqtt = "mqtt"
+const Exec = "exec"
+
+// Types lists the notifier backends that can be configured
+var Types = []string{Desktop, Log, Webhook, Email, Mqtt, Exec}
+
+// Notifier sends an Alert to the user somehow
+type Notifier interface {
+	Notify(alert types.Alert) error
+}
+
+// Router sends each Alert to the notifiers routed for its kind
+type Router struct {
+	notifiers map[string]Notifier
+	routes    map[string][]string
+}
+
+// DesktopNotifier shows alerts as desktop notifications with an icon
+type DesktopNotifier struct {
+	IconPath string
+}
+
+// LogNotifier writes alerts as lines of text
+type LogNotifier struct {
+	Writer io.Writer
+}
+
+// ExecNotifier runs a command for each alert, with the alert's title and message in the PLANESPOTTER_TITLE and
+// PLANESPOTTER_MESSAGE environment variables, and the whole alert as JSON on stdin
+type ExecNotifier struct {
+	Command string
+	Args    []string
+}
+
+// New takes a NotifierConfig and the path of the icon for desktop notifications, and returns the Notifier for it
+// Returns an error if the type is unknown or settings it needs are missing
+func New(config types.NotifierConfig, iconPath string) (Notifier, error) {
+	switch config.Type {
+	case Desktop:
+		return DesktopNotifier{IconPath: iconPath}, nil
+	case Log:
+		return LogNotifier{Writer: os.Stdout}, nil
+	case Webhook:
+		n, err := NewWebhook(config)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case Email:
+		if config.Address == "" || config.From == "" || len(config.To) == 0 {
+			return nil, fmt.Errorf("notifier %q: email needs a server, from and to addresses", config.Name)
+		}
+		return EmailNotifier{Server: config.Address, Username: config.Username, Password: config.Password, From: config.From, To: config.To}, nil
+	case Mqtt:
+		if config.Address == "" || config.Topic == "" {
+			return nil, fmt.Errorf("notifier %q: mqtt needs a broker and topic", config.Name)
+		}
+		return MqttNotifier{Broker: config.Address, Topic: config.Topic, Username: config.Username, Password: config.Password}, nil
+	case Exec:
+		if config.Address == "" {
+			return nil, fmt.Errorf("notifier %q: exec needs a command", config.Name)
+		}
+		return ExecNotifier{Command: config.Address, Args: config.Args}, nil
+	default:
+		return nil, fmt.Errorf("notifier %q: unknown type %q, expected one of %v", config.Name, config.Type, strings.Join(Types, ", "))
+	}
+}
+
+// NewRouter takes the configured notifiers, the routes from alert kind to notifier names, and the icon path for
+// desktop notifications, and returns a Router. With no notifiers configured every alert goes to the desktop,
+// and a kind with no route goes to every notifier. Webhooks are sent in the Background
+// Returns an error if a notifier has no name or the same name as another, can't be made, or a route names one
+// that doesn't exist
+func NewRouter(configs []types.NotifierConfig, routes map[string][]string, iconPath string) (*Router, error) {
+	if len(configs) == 0 {
+		configs = []types.NotifierConfig{{Name: Desktop, Type: Desktop}}
+	}
+
+	var names []string
+	named := make(map[string]bool)
+	for i, c := range configs {
+		if strings.TrimSpace(c.Name) == "" {
+			return nil, fmt.Errorf("notifier %v: expected a name", i+1)
+		}
+		if named[c.Name] {
+			return nil, fmt.Errorf("notifier %v: more than one notifier named %q", i+1, c.Name)
+		}
+		named[c.Name] = true
+		names = append(names, c.Name)
+	}
+
+	r := &Router{notifiers: make(map[string]Notifier), routes: make(map[string][]string)}
+	for _, kind := range Kinds {
+		route, ok := routes[kind]
+		if !ok {
+			route = names
+		}
+		for _, name := range route {
+			if !named[name] {
+				return nil, fmt.Errorf("route for %v alerts: no notifier named %q", kind, name)
+			}
+		}
+		r.routes[kind] = route
+	}
+
+	for _, c := range configs {
+		n, err := New(c, iconPath)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if c.Type == Webhook {
+			// Retries wait seconds between attempts, which mustn't hold up the next check
+			n = NewBackground(c.Name, n)
+		}
+		r.notifiers[c.Name] = n
+	}
+
+	return r, nil
+}
+
+// Send takes an Alert and sends it to every notifier routed for its kind
+// Returns the errors from any notifiers that failed, after trying them all
+func (r *Router) Send(alert types.Alert) error {
+	var errs []error
+	for _, name := range r.routes[alert.Kind] {
+		if err := r.notifiers[name].Notify(alert); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close stops any notifiers sending in the background, once the alerts they have queued are sent
+func (r *Router) Close() {
+	for _, n := range r.notifiers {
+		if b, ok := n.(*Background); ok {
+			b.Close()
+		}
+	}
+}
+
+// Notify shows the alert as a desktop notification
+func (n DesktopNotifier) Notify(alert types.Alert) error {
+	return beeep.Notify(alert.Title, alert.Message, n.IconPath)
+}
+
+// Notify writes the alert as a single line with its time, title and message
+func (n LogNotifier) Notify(alert types.Alert) error {
+	message := strings.Join(strings.Fields(alert.Message), " ")
+	_, err := fmt.Fprintf(n.Writer, "%v %v: %v\n", alert.Time.Format(time.RFC3339), alert.Title, message)
+	return err
+}
+
+// Notify runs the command for the alert, and returns an error including its output if it fails
+func (n ExecNotifier) Notify(alert types.Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(n.Command, n.Args...)
+	cmd.Env = append(os.Environ(), "PLANESPOTTER_TITLE="+alert.Title, "PLANESPOTTER_MESSAGE="+alert.Message)
+	cmd.Stdin = strings.NewReader(string(body))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
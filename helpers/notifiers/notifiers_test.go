@@ -0,0 +1,282 @@
+package notifiers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testAlert = types.Alert{
+	Kind:    Spotted,
+	Title:   "Plane Spotted!",
+	Message: "BAW12 \n ↑ 18227 ft",
+	Time:    time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	Planes:  []types.PlaneInfo{{Icao24: "abc123", Callsign: "BAW12"}},
+}
+
+// recorder is a Notifier that keeps the alerts it's sent
+type recorder struct {
+	alerts []types.Alert
+}
+
+func (r *recorder) Notify(alert types.Alert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		config   types.NotifierConfig
+		expected Notifier
+	}{
+		{config: types.NotifierConfig{Type: Desktop}, expected: DesktopNotifier{IconPath: "icon.png"}},
+		{config: types.NotifierConfig{Type: Webhook, Address: "http://localhost"}, expected: WebhookNotifier{Url: "http://localhost", Retries: DefaultRetries}},
+		{config: types.NotifierConfig{Type: Mqtt, Address: "localhost:1883", Topic: "planes"}, expected: MqttNotifier{Broker: "localhost:1883", Topic: "planes"}},
+		{config: types.NotifierConfig{Type: Exec, Address: "notify-send", Args: []string{"-u", "low"}}, expected: ExecNotifier{Command: "notify-send", Args: []string{"-u", "low"}}},
+		{config: types.NotifierConfig{Type: Webhook}, expected: nil},
+		{config: types.NotifierConfig{Type: Webhook, Address: "http://localhost", Template: "{{.Title"}, expected: nil},
+		{config: types.NotifierConfig{Type: Email, Address: "localhost:25"}, expected: nil},
+		{config: types.NotifierConfig{Type: "pager"}, expected: nil},
+	}
+
+	for _, test := range tests {
+		res, err := New(test.config, "icon.png")
+		assert.Equal(t, test.expected, res, "New(%+v)", test.config)
+		assert.Equal(t, test.expected == nil, err != nil, "New(%+v) error", test.config)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	r, err := NewRouter([]types.NotifierConfig{{Name: "log", Type: Log}, {Name: "hook", Type: Webhook, Address: "http://localhost"}}, map[string][]string{Spotted: {"log"}}, "")
+	assert.NoError(t, err)
+
+	assert.IsType(t, &Background{}, r.notifiers["hook"], "Webhooks are sent in the background")
+	r.Close()
+
+	spotted, everything := &recorder{}, &recorder{}
+	r.notifiers = map[string]Notifier{"log": spotted, "hook": everything}
+
+	assert.NoError(t, r.Send(testAlert))
+	assert.NoError(t, r.Send(types.Alert{Kind: Summary}))
+	assert.Len(t, spotted.alerts, 2, "Kinds without a route go to every notifier")
+	assert.Len(t, everything.alerts, 1)
+
+	_, err = NewRouter([]types.NotifierConfig{{Name: "log", Type: Log}}, map[string][]string{Spotted: {"pager"}}, "")
+	assert.Error(t, err)
+
+	_, err = NewRouter([]types.NotifierConfig{{Name: "log", Type: Log}, {Name: "log", Type: Webhook, Address: "http://localhost"}}, nil, "")
+	assert.EqualError(t, err, `notifier 2: more than one notifier named "log"`)
+
+	_, err = NewRouter([]types.NotifierConfig{{Name: " ", Type: Log}}, nil, "")
+	assert.EqualError(t, err, "notifier 1: expected a name")
+
+	r, err = NewRouter(nil, nil, "icon.png")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{Desktop}, r.routes[Approaching])
+}
+
+// slowNotifier blocks each alert until it is released
+type slowNotifier struct {
+	release chan bool
+	sent    int
+}
+
+func (n *slowNotifier) Notify(alert types.Alert) error {
+	<-n.release
+	n.sent++
+	return nil
+}
+
+func TestBackground(t *testing.T) {
+	slow := &slowNotifier{release: make(chan bool)}
+	b := NewBackground("slow", slow)
+
+	// Alerts are queued without waiting for the notifier, until the queue is full. One may already have been taken
+	// from the queue, so that can hold one more
+	accepted := 0
+	for i := 0; i < QueueSize+2; i++ {
+		if b.Notify(testAlert) == nil {
+			accepted++
+		}
+	}
+	assert.GreaterOrEqual(t, accepted, QueueSize)
+	assert.Less(t, accepted, QueueSize+2)
+
+	close(slow.release)
+	b.Close()
+	assert.Equal(t, accepted, slow.sent, "Queued alerts are sent before Close returns")
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, LogNotifier{Writer: &buf}.Notify(testAlert))
+	assert.Equal(t, "2026-10-19T12:00:00Z Plane Spotted!: BAW12 ↑ 18227 ft\n", buf.String())
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received types.Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, WebhookNotifier{Url: server.URL}.Notify(testAlert))
+	assert.Equal(t, testAlert, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	assert.Error(t, WebhookNotifier{Url: failing.URL}.Notify(testAlert))
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	var body, contentType, auth, signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, contentType, auth, signature = string(b), r.Header.Get("Content-Type"), r.Header.Get("Authorization"), r.Header.Get(SignatureHeader)
+	}))
+	defer server.Close()
+
+	n, err := NewWebhook(types.NotifierConfig{
+		Address:  server.URL,
+		Template: `{"text": {{json .Message}}, "callsign": "{{(index .Planes 0).Callsign | lower}}"}`,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Secret:   "shh",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, n.Notify(testAlert))
+
+	assert.Equal(t, `{"text": "BAW12 \n ↑ 18227 ft", "callsign": "baw12"}`, body)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, Sign("shh", []byte(body)), signature)
+
+	// Plain text services like ntfy can set their own content type
+	n, _ = NewWebhook(types.NotifierConfig{Address: server.URL, Template: "{{.Title}}", Headers: map[string]string{"Content-Type": "text/plain"}})
+	assert.NoError(t, n.Notify(testAlert))
+	assert.Equal(t, "Plane Spotted!", body)
+	assert.Equal(t, "text/plain", contentType)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	webhookBackoff = time.Millisecond
+	defer func() { webhookBackoff = time.Second }()
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, WebhookNotifier{Url: server.URL, Retries: 3}.Notify(testAlert))
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	assert.Error(t, WebhookNotifier{Url: server.URL, Retries: 1}.Notify(testAlert))
+	assert.Equal(t, 2, attempts)
+
+	// Client errors aren't retried
+	attempts = 0
+	badRequest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer badRequest.Close()
+
+	assert.Error(t, WebhookNotifier{Url: badRequest.URL, Retries: 3}.Notify(testAlert))
+	assert.Equal(t, 1, attempts)
+
+	// 0 is the default, so none is asked for with NoRetries
+	n, _ := NewWebhook(types.NotifierConfig{Address: server.URL, Retries: NoRetries})
+	assert.Equal(t, 0, n.Retries)
+	attempts = 0
+	assert.Error(t, n.Notify(testAlert))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestSign(t *testing.T) {
+	// From RFC 4231 test case 2
+	assert.Equal(t, "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843", Sign("Jefe", []byte("what do ya want for nothing?")))
+}
+
+func TestEmailMessage(t *testing.T) {
+	res := string(emailMessage("spotter@example.com", []string{"me@example.com", "you@example.com"}, testAlert))
+
+	assert.Contains(t, res, "To: me@example.com, you@example.com\r\n")
+	assert.Contains(t, res, "Subject: Plane Spotted!\r\n")
+	assert.True(t, strings.HasSuffix(res, "\r\n\r\nBAW12\r\n↑ 18227 ft\r\n"))
+}
+
+func TestMqttNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// A broker that accepts the connection and keeps everything sent after the connack
+	received := make(chan []byte)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		header := make([]byte, 2)
+		io.ReadFull(conn, header)
+		io.ReadFull(conn, make([]byte, header[1]))
+		conn.Write([]byte{mqttConnack, 2, 0, 0})
+
+		rest, _ := io.ReadAll(conn)
+		received <- rest
+	}()
+
+	err = MqttNotifier{Broker: listener.Addr().String(), Topic: "planes", Username: "user", Password: "pass"}.Notify(testAlert)
+	assert.NoError(t, err)
+
+	payload, _ := json.Marshal(testAlert)
+	expected := append(mqttPublishPacket("planes", payload), mqttDisconnect, 0)
+	assert.Equal(t, expected, <-received)
+}
+
+func TestMqttPacket(t *testing.T) {
+	assert.Equal(t, []byte{mqttPublish, 3, 0, 1, 'a'}, mqttPacket(mqttPublish, mqttString("a")))
+
+	// 200 bytes takes two bytes of remaining length
+	res := mqttPacket(mqttPublish, make([]byte, 200))
+	assert.Equal(t, []byte{mqttPublish, 0xc8, 0x01}, res[:3])
+	assert.Len(t, res, 203)
+
+	res = mqttConnectPacket("user", "pass")
+	assert.Equal(t, byte(0xc2), res[9], "Connect flags should have username, password and clean session set")
+}
+
+func TestExecNotifier(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.txt")
+	n := ExecNotifier{Command: "sh", Args: []string{"-c", `echo "$PLANESPOTTER_TITLE" > ` + out + ` && cat >> ` + out}}
+
+	assert.NoError(t, n.Notify(testAlert))
+	res, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(res), "Plane Spotted!\n{\"Kind\":\"spotted\""))
+
+	assert.ErrorContains(t, ExecNotifier{Command: "sh", Args: []string{"-c", "echo broken >&2; exit 1"}}.Notify(testAlert), "broken")
+}
@@ -0,0 +1,148 @@
+package notifiers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"planespotter/helpers/types"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SignatureHeader is the header a webhook's HMAC-SHA256 signature of the body is sent in, when it has a secret
+const SignatureHeader = "X-Planespotter-Signature"
+
+// DefaultRetries is how many times a failed webhook is retried when the config doesn't say
+const DefaultRetries = 3
+
+// NoRetries is the Retries to configure for a webhook that shouldn't be retried, as 0 means DefaultRetries
+const NoRetries = -1
+
+// WebhookNotifier posts each alert to a url, as JSON or the body from its Template
+// Headers are added to every request, and if Secret is set the body is signed with it in SignatureHeader
+// Failed requests are retried Retries times, waiting twice as long before each retry
+type WebhookNotifier struct {
+	Url      string
+	Template *template.Template
+	Headers  map[string]string
+	Secret   string
+	Retries  int
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookBackoff is how long to wait before the first retry
+var webhookBackoff = time.Second
+
+// templateFuncs are the extra functions available in webhook templates
+var templateFuncs = template.FuncMap{
+	// json quotes a value as JSON, so messages with quotes and new lines can go in a JSON body
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NewWebhook takes a NotifierConfig and returns its WebhookNotifier, retried DefaultRetries times unless the
+// config says otherwise, or not at all with NoRetries
+// Returns an error if the url is missing or the template can't be parsed
+func NewWebhook(config types.NotifierConfig) (WebhookNotifier, error) {
+	if config.Address == "" {
+		return WebhookNotifier{}, fmt.Errorf("notifier %q: webhook needs a url", config.Name)
+	}
+
+	n := WebhookNotifier{Url: config.Address, Headers: config.Headers, Secret: config.Secret, Retries: config.Retries}
+	switch {
+	case n.Retries == 0:
+		n.Retries = DefaultRetries
+	case n.Retries < 0:
+		n.Retries = 0
+	}
+	if config.Template != "" {
+		t, err := template.New(config.Name).Funcs(templateFuncs).Parse(config.Template)
+		if err != nil {
+			return WebhookNotifier{}, fmt.Errorf("notifier %q: %w", config.Name, err)
+		}
+		n.Template = t
+	}
+
+	return n, nil
+}
+
+// Notify posts the alert to the webhook, retrying if it can't be reached or responds with a server error
+// Returns an error if it still fails after every retry, or responds with any other status outside 2xx
+func (n WebhookNotifier) Notify(alert types.Alert) error {
+	body, err := n.Body(alert)
+	if err != nil {
+		return err
+	}
+
+	wait := webhookBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(body)
+		if err == nil || !retry || attempt >= n.Retries {
+			return err
+		}
+
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// Body takes an Alert and returns the request body for it, from the Template or as JSON if there isn't one
+func (n WebhookNotifier) Body(alert types.Alert) ([]byte, error) {
+	if n.Template == nil {
+		return json.Marshal(alert)
+	}
+
+	var buf bytes.Buffer
+	if err := n.Template.Execute(&buf, alert); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Sign takes a secret and a body, and returns the value of SignatureHeader for them
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends the body to the webhook once, and returns whether it is worth retrying if it fails
+func (n WebhookNotifier) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, n.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.Headers {
+		req.Header.Set(name, value)
+	}
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook responded with status %v", resp.StatusCode)
+	}
+
+	return false, nil
+}
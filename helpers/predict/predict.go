@@ -0,0 +1,123 @@
+// Package predict projects planes forward along their great-circle track to forecast their closest point of
+// approach (CPA) to the observer, so they can be alerted before they come overhead.
+
+package predict
+
+import (
+	"math"
+	"planespotter/helpers/geo"
+	"planespotter/helpers/types"
+	"sync"
+	"time"
+)
+
+// cruiseKmPerMinute is roughly an airliner's cruising ground speed, used to size the search for approaching planes
+const cruiseKmPerMinute = 15.0
+
+// Pass is a forecast of a plane's closest point of approach to the observer, assuming it holds its speed and track
+type Pass struct {
+	Plane         types.PlaneInfo
+	TimeToCpa     time.Duration
+	CpaDistanceKm float64
+	ElevationDeg  float64
+	Cpa           types.Position
+}
+
+// Watcher remembers which planes have been alerted, so each approach is only alerted once
+type Watcher struct {
+	mu      sync.Mutex
+	alerted map[string]time.Time
+}
+
+// NewWatcher returns an empty Watcher
+func NewWatcher() *Watcher {
+	return &Watcher{alerted: make(map[string]time.Time)}
+}
+
+// Predict takes the observer's Position and a plane, and returns the plane's Pass, projecting it forward along the
+// great circle of its true track at its current velocity and vertical rate
+// The elevation at the CPA is looked up with geo.Look, so it allows for the observer's ElevationM
+// A plane that is moving away has its CPA now. Returns false if the plane has no position or isn't moving
+func Predict(observer types.Position, plane types.PlaneInfo) (Pass, bool) {
+	s := plane.State
+	if !s.Has_Position || s.Velocity <= 0 {
+		return Pass{}, false
+	}
+
+	start := types.Position{Latitude: s.Latitude, Longitude: s.Longitude}
+	toObserver := geo.DistanceKm(start, observer) / geo.EarthRadiusKm
+	offTrack := toRadians(geo.Bearing(start, observer) - s.True_Track)
+
+	// Cross-track is how far the observer is from the plane's path, along-track how far ahead the CPA is
+	crossTrack := math.Asin(math.Sin(toObserver) * math.Sin(offTrack))
+	alongTrack := 0.0
+	cpaAngle := toObserver
+	if math.Cos(offTrack) > 0 {
+		alongTrack = math.Acos(math.Min(1, math.Cos(toObserver)/math.Cos(crossTrack)))
+		cpaAngle = math.Abs(crossTrack)
+	}
+
+	timeToCpa := alongTrack * geo.EarthRadiusKm * 1000 / s.Velocity
+	altitude := s.Baro_Altitude
+	if s.Has_Geo_Altitude {
+		altitude = s.Geo_Altitude
+	}
+	altitude = math.Max(0, altitude+s.Vertical_Rate*timeToCpa)
+
+	cpa := geo.Destination(start, s.True_Track, alongTrack*geo.EarthRadiusKm)
+	look, _ := geo.Look(observer, types.StateVector{
+		Has_Position: true, Latitude: cpa.Latitude, Longitude: cpa.Longitude, Has_Geo_Altitude: true, Geo_Altitude: altitude,
+	})
+
+	return Pass{
+		Plane:         plane,
+		TimeToCpa:     time.Duration(timeToCpa * float64(time.Second)),
+		CpaDistanceKm: cpaAngle * geo.EarthRadiusKm,
+		ElevationDeg:  look.ElevationDeg,
+		Cpa:           cpa,
+	}, true
+}
+
+// SearchRadiusKm takes a PassAlert and returns the radius around the observer to search for planes that could
+// reach it within the alert's time at cruising speed
+func SearchRadiusKm(alert types.PassAlert) int {
+	return int(math.Ceil(alert.WithinKm + float64(alert.Minutes)*cruiseKmPerMinute))
+}
+
+// Due takes planes near the observer, the observer's Position, the PassAlert settings and the current time, and
+// returns the Passes to alert: planes not yet within WithinKm that are predicted to come within it in the next
+// Minutes. Each plane is only returned once until it has not been due for twice the alert's time
+func (w *Watcher) Due(planeInfos []types.PlaneInfo, observer types.Position, alert types.PassAlert, now time.Time) []Pass {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	window := time.Duration(alert.Minutes) * time.Minute
+	for key, t := range w.alerted {
+		if now.Sub(t) > 2*window {
+			delete(w.alerted, key)
+		}
+	}
+
+	var due []Pass
+	for _, p := range planeInfos {
+		pass, ok := Predict(observer, p)
+		if !ok || pass.TimeToCpa <= 0 || pass.TimeToCpa > window || pass.CpaDistanceKm > alert.WithinKm {
+			continue
+		}
+		if geo.DistanceKm(types.Position{Latitude: p.State.Latitude, Longitude: p.State.Longitude}, observer) <= alert.WithinKm {
+			continue
+		}
+
+		key := p.Icao24 + p.Callsign
+		if _, alerted := w.alerted[key]; !alerted {
+			due = append(due, pass)
+		}
+		w.alerted[key] = now
+	}
+
+	return due
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,76 @@
+package predict
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var observer = types.Position{Latitude: 51.5, Longitude: -0.1}
+
+// inbound is 0.5 degrees due west of the observer heading east at 200 m/s and 1000 m, so will pass nearly overhead
+// A great circle heading east curves north of the line of latitude, so it passes just north of the observer
+var inbound = types.PlaneInfo{Icao24: "inbound", Callsign: "IN1", State: types.StateVector{
+	Has_Position: true, Latitude: 51.5, Longitude: -0.6, Has_Baro_Altitude: true, Baro_Altitude: 1000, Velocity: 200, True_Track: 90,
+}}
+
+func TestPredict(t *testing.T) {
+	pass, ok := Predict(observer, inbound)
+	assert.True(t, ok)
+	assert.InDelta(t, 173, pass.TimeToCpa.Seconds(), 1)
+	assert.InDelta(t, 0.12, pass.CpaDistanceKm, 0.01)
+	assert.InDelta(t, 83.3, pass.ElevationDeg, 0.1)
+	assert.InDelta(t, observer.Longitude, pass.Cpa.Longitude, 0.001)
+
+	// From a hill 500m up the plane is only 500m above, so it passes lower in the sky
+	hill := observer
+	hill.ElevationM = 500
+	pass, _ = Predict(hill, inbound)
+	assert.InDelta(t, 0.12, pass.CpaDistanceKm, 0.01)
+	assert.InDelta(t, 76.7, pass.ElevationDeg, 0.1)
+
+	// Heading north east it misses by about 24km and is low on the horizon
+	offset := inbound
+	offset.State.True_Track = 45
+	pass, _ = Predict(observer, offset)
+	assert.InDelta(t, 24.4, pass.CpaDistanceKm, 0.5)
+	assert.InDelta(t, 2.2, pass.ElevationDeg, 0.2)
+
+	// Heading away its closest approach is now
+	away := inbound
+	away.State.True_Track = 270
+	pass, _ = Predict(observer, away)
+	assert.Equal(t, time.Duration(0), pass.TimeToCpa)
+	assert.InDelta(t, 34.6, pass.CpaDistanceKm, 0.5)
+
+	_, ok = Predict(observer, types.PlaneInfo{})
+	assert.False(t, ok)
+}
+
+func TestDue(t *testing.T) {
+	w := NewWatcher()
+	now := time.Now()
+	alert := types.PassAlert{WithinKm: 5, Minutes: 5}
+
+	overhead := inbound
+	overhead.Icao24 = "overhead"
+	overhead.State.Longitude = -0.12
+
+	slow := inbound
+	slow.Icao24 = "slow"
+	slow.State.Velocity = 50
+
+	due := w.Due([]types.PlaneInfo{inbound, overhead, slow}, observer, alert, now)
+	assert.Len(t, due, 1, "Only planes not yet close and arriving within the time should be due")
+	assert.Equal(t, "inbound", due[0].Plane.Icao24)
+
+	assert.Empty(t, w.Due([]types.PlaneInfo{inbound}, observer, alert, now.Add(time.Minute)), "A plane should only be alerted once")
+	assert.Len(t, w.Due([]types.PlaneInfo{inbound}, observer, alert, now.Add(20*time.Minute)), 1)
+}
+
+func TestSearchRadiusKm(t *testing.T) {
+	assert.Equal(t, 155, SearchRadiusKm(types.PassAlert{WithinKm: 5, Minutes: 10}))
+	assert.Equal(t, 3, SearchRadiusKm(types.PassAlert{WithinKm: 2.5}))
+}
@@ -0,0 +1,150 @@
+// Package profiles manages named observer locations, each with its own position, spot area,
+// data source, notification schedule and progress, and switching the active configuration between them.
+
+package profiles
+
+import (
+	"fmt"
+	"planespotter/helpers/types"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Find takes a slice of Profile and a name, and returns the index of the profile with that name, ignoring case
+// Returns -1 if there is no such profile
+func Find(profiles []types.Profile, name string) int {
+	return slices.IndexFunc(profiles, func(p types.Profile) bool {
+		return strings.EqualFold(p.Name, name)
+	})
+}
+
+// Names takes a slice of Profile and returns their names in order
+func Names(profiles []types.Profile) []string {
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+// Switch takes a Config and the name of a profile, and returns the Config with that profile's settings active
+// Returns an error if there is no profile with that name
+func Switch(config types.Config, name string) (types.Config, error) {
+	i := Find(config.Profiles, name)
+	if i == -1 {
+		return config, fmt.Errorf("no profile named %q", name)
+	}
+
+	p := config.Profiles[i]
+	config.ActiveProfile = p.Name
+	config.Position = p.Position
+	config.SpotDistanceKm = p.SpotDistanceKm
+	config.CheckFreqSeconds = p.CheckFreqSeconds
+	config.Shape = p.Shape
+	config.Volume = p.Volume
+	config.DataSource = p.DataSource
+	config.Schedule = p.Schedule
+
+	return config, nil
+}
+
+// Store takes a Config and a profile name, and returns the Config with its current settings saved under that name
+// and made the active profile. An existing profile with the name keeps its progress, otherwise a new one is added
+// Returns an error if the name is blank
+func Store(config types.Config, name string) (types.Config, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return config, fmt.Errorf("profile name can't be blank")
+	}
+
+	profiles := slices.Clone(config.Profiles)
+	i := Find(profiles, name)
+	if i == -1 {
+		profiles = append(profiles, types.Profile{Name: name})
+		i = len(profiles) - 1
+	}
+
+	profiles[i].Position = config.Position
+	profiles[i].SpotDistanceKm = config.SpotDistanceKm
+	profiles[i].CheckFreqSeconds = config.CheckFreqSeconds
+	profiles[i].Shape = config.Shape
+	profiles[i].Volume = config.Volume
+	profiles[i].DataSource = config.DataSource
+	profiles[i].Schedule = config.Schedule
+
+	config.Profiles = profiles
+	config.ActiveProfile = profiles[i].Name
+	return config, nil
+}
+
+// Delete takes a Config and a profile name, and returns the Config without that profile
+// The current settings are left as they are, but are no longer attached to a profile if it was the active one
+func Delete(config types.Config, name string) types.Config {
+	i := Find(config.Profiles, name)
+	if i == -1 {
+		return config
+	}
+
+	if strings.EqualFold(config.ActiveProfile, name) {
+		config.ActiveProfile = ""
+	}
+	config.Profiles = slices.Delete(slices.Clone(config.Profiles), i, i+1)
+
+	return config
+}
+
+// AddCallsign takes a Config, a profile name and a callsign, and returns the Config with the callsign added to that
+// profile's progress if it hasn't been seen there before. Does nothing if there is no profile with the name
+func AddCallsign(config types.Config, profile string, callsign string) types.Config {
+	i := Find(config.Profiles, profile)
+	if i == -1 || slices.Contains(config.Profiles[i].Progress.Callsigns, callsign) {
+		return config
+	}
+
+	profiles := slices.Clone(config.Profiles)
+	profiles[i].Progress.SeenCount += 1
+	profiles[i].Progress.Callsigns = append(slices.Clone(profiles[i].Progress.Callsigns), callsign)
+	config.Profiles = profiles
+
+	return config
+}
+
+// Owner takes a Config and the name of the Area a plane was found in, and returns the name and position of the
+// profile it belongs to. Anything not found in another watched profile's area, such as a watchlisted plane,
+// belongs to the active settings
+func Owner(config types.Config, area string) (string, types.Position) {
+	if i := Find(config.Profiles, area); i != -1 && !strings.EqualFold(area, config.ActiveProfile) {
+		return config.Profiles[i].Name, config.Profiles[i].Position
+	}
+
+	return config.ActiveProfile, config.Position
+}
+
+// Areas takes a Config and returns the spot areas to watch: the active settings first, named after the active
+// profile, then any other profiles marked to be watched as well
+func Areas(config types.Config) []types.Area {
+	areas := []types.Area{{Name: config.ActiveProfile, Position: config.Position, SpotDistanceKm: config.SpotDistanceKm, Shape: config.Shape, Volume: config.Volume}}
+	for _, p := range config.Profiles {
+		if p.Watch && !strings.EqualFold(p.Name, config.ActiveProfile) {
+			areas = append(areas, types.Area{Name: p.Name, Position: p.Position, SpotDistanceKm: p.SpotDistanceKm, Shape: p.Shape, Volume: p.Volume})
+		}
+	}
+
+	return areas
+}
+
+// SetWatched takes a Config and the names of the profiles to watch, and returns the Config with only those
+// profiles marked to be watched
+func SetWatched(config types.Config, names []string) types.Config {
+	profiles := slices.Clone(config.Profiles)
+	for i := range profiles {
+		profiles[i].Watch = slices.ContainsFunc(names, func(name string) bool {
+			return strings.EqualFold(name, profiles[i].Name)
+		})
+	}
+	config.Profiles = profiles
+
+	return config
+}
@@ -0,0 +1,149 @@
+package profiles
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var home = types.Profile{
+	Name:             "Home",
+	Position:         types.Position{Latitude: 51.5, Longitude: -0.1},
+	SpotDistanceKm:   20,
+	CheckFreqSeconds: 60,
+	Progress:         types.Progress{SeenCount: 1, Callsigns: []string{"BAW12"}},
+}
+
+var airfield = types.Profile{
+	Name:             "Airfield",
+	Position:         types.Position{Latitude: 51.15, Longitude: -0.19},
+	SpotDistanceKm:   5,
+	CheckFreqSeconds: 30,
+	Shape:            types.SpotShape{Type: "corridor", Points: []types.Position{{Latitude: 51.15, Longitude: -0.3}, {Latitude: 51.15, Longitude: -0.1}}, CorridorWidthKm: 1},
+	Volume:           types.Volume{MaxAltitudeM: 1000, VerticalRate: "descending"},
+	DataSource:       "http://localhost:8080/states/all",
+	Schedule:         types.Schedule{Days: []string{"Sat", "Sun"}, QuietStart: "22:00", QuietEnd: "07:00"},
+}
+
+func TestFind(t *testing.T) {
+	profiles := []types.Profile{home, airfield}
+
+	assert.Equal(t, 0, Find(profiles, "Home"))
+	assert.Equal(t, 1, Find(profiles, "airfield"))
+	assert.Equal(t, -1, Find(profiles, "Office"))
+	assert.Equal(t, []string{"Home", "Airfield"}, Names(profiles))
+}
+
+func TestSwitch(t *testing.T) {
+	config := types.Config{ActiveProfile: "Home", Position: home.Position, SpotDistanceKm: 20, CheckFreqSeconds: 60, Profiles: []types.Profile{home, airfield}}
+
+	res, err := Switch(config, "airfield")
+	assert.NoError(t, err)
+	assert.Equal(t, "Airfield", res.ActiveProfile)
+	assert.Equal(t, airfield.Position, res.Position)
+	assert.Equal(t, 5, res.SpotDistanceKm)
+	assert.Equal(t, 30, res.CheckFreqSeconds)
+	assert.Equal(t, airfield.Shape, res.Shape)
+	assert.Equal(t, airfield.Volume, res.Volume)
+	assert.Equal(t, airfield.DataSource, res.DataSource)
+	assert.Equal(t, airfield.Schedule, res.Schedule)
+
+	_, err = Switch(config, "Office")
+	assert.Error(t, err)
+}
+
+func TestStore(t *testing.T) {
+	config := types.Config{ActiveProfile: "Home", Position: types.Position{Latitude: 52, Longitude: 0}, SpotDistanceKm: 10, CheckFreqSeconds: 90, Profiles: []types.Profile{home}}
+
+	// Updating an existing profile keeps its progress
+	res, err := Store(config, "home")
+	assert.NoError(t, err)
+	assert.Len(t, res.Profiles, 1)
+	assert.Equal(t, types.Profile{Name: "Home", Position: types.Position{Latitude: 52, Longitude: 0}, SpotDistanceKm: 10, CheckFreqSeconds: 90, Progress: home.Progress}, res.Profiles[0])
+	assert.Equal(t, types.Position{Latitude: 51.5, Longitude: -0.1}, config.Profiles[0].Position, "Store should not modify the original config")
+
+	res, err = Store(config, " Office ")
+	assert.NoError(t, err)
+	assert.Len(t, res.Profiles, 2)
+	assert.Equal(t, "Office", res.ActiveProfile)
+	assert.Equal(t, types.Profile{Name: "Office", Position: types.Position{Latitude: 52, Longitude: 0}, SpotDistanceKm: 10, CheckFreqSeconds: 90}, res.Profiles[1])
+
+	_, err = Store(config, "  ")
+	assert.Error(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	config := types.Config{ActiveProfile: "Home", Profiles: []types.Profile{home, airfield}}
+
+	res := Delete(config, "Home")
+	assert.Equal(t, "", res.ActiveProfile)
+	assert.Equal(t, []types.Profile{airfield}, res.Profiles)
+	assert.Len(t, config.Profiles, 2)
+
+	res = Delete(config, "Airfield")
+	assert.Equal(t, "Home", res.ActiveProfile)
+	assert.Equal(t, []types.Profile{home}, res.Profiles)
+
+	assert.Equal(t, config, Delete(config, "Office"))
+}
+
+func TestAddCallsign(t *testing.T) {
+	config := types.Config{ActiveProfile: "Home", Profiles: []types.Profile{home, airfield}}
+
+	res := AddCallsign(config, "Home", "EZY34")
+	assert.Equal(t, types.Progress{SeenCount: 2, Callsigns: []string{"BAW12", "EZY34"}}, res.Profiles[0].Progress)
+	assert.Equal(t, types.Progress{}, res.Profiles[1].Progress)
+	assert.Equal(t, 1, config.Profiles[0].Progress.SeenCount)
+
+	assert.Equal(t, config, AddCallsign(config, "Home", "BAW12"))
+	assert.Equal(t, config, AddCallsign(config, "", "EZY34"))
+
+	res = AddCallsign(config, "airfield", "EZY34")
+	assert.Equal(t, types.Progress{SeenCount: 1, Callsigns: []string{"EZY34"}}, res.Profiles[1].Progress)
+}
+
+func TestOwner(t *testing.T) {
+	config := types.Config{ActiveProfile: "Home", Position: types.Position{Latitude: 51.5}, Profiles: []types.Profile{home, airfield}}
+
+	name, position := Owner(config, "Airfield")
+	assert.Equal(t, airfield.Name, name)
+	assert.Equal(t, airfield.Position, position)
+
+	// The active profile's live settings are used rather than those last stored, as are they for other planes
+	for _, area := range []string{"Home", "", "watchlist"} {
+		name, position = Owner(config, area)
+		assert.Equal(t, "Home", name)
+		assert.Equal(t, types.Position{Latitude: 51.5}, position)
+	}
+}
+
+func TestAreas(t *testing.T) {
+	watchedAirfield := airfield
+	watchedAirfield.Watch = true
+	watchedHome := home
+	watchedHome.Watch = true
+	config := types.Config{ActiveProfile: "Home", Position: types.Position{Latitude: 52, Longitude: 0}, SpotDistanceKm: 10, Profiles: []types.Profile{watchedHome, watchedAirfield}}
+
+	assert.Equal(t, []types.Area{
+		{Name: "Home", Position: types.Position{Latitude: 52, Longitude: 0}, SpotDistanceKm: 10},
+		{Name: "Airfield", Position: airfield.Position, SpotDistanceKm: 5, Shape: airfield.Shape, Volume: airfield.Volume},
+	}, Areas(config))
+
+	config.Profiles = []types.Profile{home, airfield}
+	assert.Len(t, Areas(config), 1)
+
+	assert.Equal(t, []types.Area{{SpotDistanceKm: 20}}, Areas(types.Config{SpotDistanceKm: 20}))
+}
+
+func TestSetWatched(t *testing.T) {
+	config := types.Config{Profiles: []types.Profile{home, airfield}}
+
+	res := SetWatched(config, []string{"airfield"})
+	assert.False(t, res.Profiles[0].Watch)
+	assert.True(t, res.Profiles[1].Watch)
+	assert.False(t, config.Profiles[1].Watch)
+
+	res = SetWatched(res, nil)
+	assert.False(t, res.Profiles[1].Watch)
+}
@@ -0,0 +1,86 @@
+// Package schedule decides when notifications should be quiet, from daily quiet hours and the days of the week
+// they are wanted, and keeps the planes missed while quiet so they can be summarised afterwards.
+
+package schedule
+
+import (
+	"fmt"
+	"planespotter/helpers/batch"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// Weekdays lists the day names used in a Schedule, starting from Monday
+var Weekdays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// Missed keeps the planes that weren't notified during quiet hours
+type Missed struct {
+	mu     sync.Mutex
+	planes []types.PlaneInfo
+}
+
+// IsQuiet takes a Schedule and a time, and returns true if notifications should be quiet then: on a day not in
+// Days, or between QuietStart and QuietEnd. A quiet period ending before it starts runs past midnight
+// Blank or unreadable quiet hours are never quiet, and no Days means every day
+func IsQuiet(schedule types.Schedule, t time.Time) bool {
+	if len(schedule.Days) > 0 && !slices.Contains(schedule.Days, weekday(t)) {
+		return true
+	}
+
+	start, startErr := ParseClock(schedule.QuietStart)
+	end, endErr := ParseClock(schedule.QuietEnd)
+	if startErr != nil || endErr != nil || start == end {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+
+	return now >= start || now < end
+}
+
+// ParseClock takes a 24 hour time such as "22:30", and returns the minutes since midnight
+// Returns an error if it isn't a valid time
+func ParseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("expected a 24 hour time like 22:30, got %q", clock)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Add takes a plane that wasn't notified and keeps it for the summary
+func (m *Missed) Add(p types.PlaneInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.planes = append(m.planes, p)
+}
+
+// Flush returns the planes missed so far and forgets them
+func (m *Missed) Flush() []types.PlaneInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	planes := m.planes
+	m.planes = nil
+	return planes
+}
+
+// Summary takes the missed planes and returns a notification message listing them
+func Summary(planes []types.PlaneInfo) string {
+	return i18n.T("notify.missed", len(planes), batch.List(planes))
+}
+
+// weekday takes a time and returns its day name as used in Weekdays
+func weekday(t time.Time) string {
+	return t.Weekday().String()[:3]
+}
@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsQuiet(t *testing.T) {
+	// 19th October 2026 is a Monday
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 19, hour, minute, 0, 0, time.UTC)
+	}
+	overnight := types.Schedule{QuietStart: "22:00", QuietEnd: "07:00"}
+	lunch := types.Schedule{QuietStart: "12:00", QuietEnd: "13:30"}
+
+	tests := []struct {
+		schedule types.Schedule
+		t        time.Time
+		expected bool
+	}{
+		{schedule: types.Schedule{}, t: at(3, 0), expected: false},
+		{schedule: overnight, t: at(3, 0), expected: true},
+		{schedule: overnight, t: at(22, 0), expected: true},
+		{schedule: overnight, t: at(7, 0), expected: false},
+		{schedule: overnight, t: at(12, 0), expected: false},
+		{schedule: lunch, t: at(12, 45), expected: true},
+		{schedule: lunch, t: at(13, 30), expected: false},
+		{schedule: types.Schedule{QuietStart: "late", QuietEnd: "07:00"}, t: at(3, 0), expected: false},
+		{schedule: types.Schedule{Days: []string{"Sat", "Sun"}}, t: at(12, 0), expected: true},
+		{schedule: types.Schedule{Days: []string{"Mon"}}, t: at(12, 0), expected: false},
+		{schedule: types.Schedule{Days: []string{"Mon"}, QuietStart: "22:00", QuietEnd: "07:00"}, t: at(23, 0), expected: true},
+	}
+
+	for _, test := range tests {
+		res := IsQuiet(test.schedule, test.t)
+		assert.Equal(t, test.expected, res, "IsQuiet(%+v, %v)", test.schedule, test.t)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	res, err := ParseClock(" 22:30 ")
+	assert.NoError(t, err)
+	assert.Equal(t, 1350, res)
+
+	_, err = ParseClock("25:00")
+	assert.Error(t, err)
+}
+
+func TestMissed(t *testing.T) {
+	var m Missed
+	for _, callsign := range []string{"BAW1", "BAW2", "BAW3", "BAW4", "BAW5", "BAW6", "BAW7"} {
+		m.Add(types.PlaneInfo{Callsign: callsign})
+	}
+
+	planes := m.Flush()
+	assert.Len(t, planes, 7)
+	assert.Empty(t, m.Flush())
+	assert.Equal(t, "7 missed while quiet: BAW1, BAW2, BAW3, BAW4, BAW5, and 2 more", Summary(planes))
+	assert.Equal(t, "1 missed while quiet: BAW1", Summary(planes[:1]))
+}
@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultIterations is how many PBKDF2 rounds turn the passphrase into the file's key, making guessing it slow
+const DefaultIterations = 600000
+
+// ErrPassphrase is returned when the secrets file can't be decrypted, because the passphrase is wrong or the file is damaged
+var ErrPassphrase = errors.New("wrong passphrase, or the secrets file is damaged")
+
+// FileStore keeps secrets in a file encrypted with AES-256-GCM, using a key derived from a passphrase
+// with PBKDF2-HMAC-SHA256. The whole file is rewritten with a new salt and nonce on every change
+type FileStore struct {
+	Path       string
+	Passphrase string
+	Iterations int
+}
+
+// encryptedFile is the secrets file as saved. The secrets are a JSON object of key to value, encrypted into Data
+type encryptedFile struct {
+	Iterations int
+	Salt       []byte
+	Nonce      []byte
+	Data       []byte
+}
+
+// NewFileStore takes the path to the secrets file and its passphrase, and returns a FileStore for it
+// The file is created when the first secret is set
+func NewFileStore(path, passphrase string) *FileStore {
+	return &FileStore{Path: path, Passphrase: passphrase, Iterations: DefaultIterations}
+}
+
+// Get takes a key and returns its secret, or ErrNotFound if the file doesn't have it
+// Returns ErrPassphrase if the file can't be decrypted
+func (f *FileStore) Get(key string) (string, error) {
+	values, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return value, nil
+}
+
+// Set takes a key and a secret and saves it in the file
+func (f *FileStore) Set(key, value string) error {
+	values, err := f.read()
+	if err != nil {
+		return err
+	}
+	values[key] = value
+
+	return f.write(values)
+}
+
+// Delete takes a key and removes its secret from the file
+func (f *FileStore) Delete(key string) error {
+	values, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok {
+		return ErrNotFound
+	}
+	delete(values, key)
+
+	return f.write(values)
+}
+
+// read decrypts the file and returns its secrets, or none if there is no file yet
+func (f *FileStore) read() (map[string]string, error) {
+	values := make(map[string]string)
+	content, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("reading secrets file: %w", err)
+	}
+	aead, err := newAead(f.Passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != aead.NonceSize() {
+		return nil, ErrPassphrase
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("reading secrets file: %w", err)
+	}
+
+	return values, nil
+}
+
+// write encrypts the secrets with a new salt and nonce and replaces the file with them, readable only by the user
+func (f *FileStore) write(values map[string]string) error {
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Iterations: f.Iterations, Salt: make([]byte, 16)}
+	if file.Iterations <= 0 {
+		file.Iterations = DefaultIterations
+	}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	aead, err := newAead(f.Passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = aead.Seal(nil, file.Nonce, plain, nil)
+
+	content, err := json.MarshalIndent(file, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, content, 0600)
+}
+
+// newAead takes a passphrase, salt and number of iterations, and returns AES-256-GCM keyed from them
+func newAead(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("the secrets file needs a passphrase")
+	}
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), salt, iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 takes a password, salt, number of iterations and key length, and returns the PBKDF2-HMAC-SHA256 key
+// as described in RFC 8018
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := 1; len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
@@ -0,0 +1,193 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const secretService = "org.freedesktop.secrets"
+const servicePath = dbus.ObjectPath("/org/freedesktop/secrets")
+const noPrompt = dbus.ObjectPath("/")
+
+// promptTimeout is how long to wait for the user to unlock the keyring
+const promptTimeout = 2 * time.Minute
+
+// KeyringStore keeps secrets in the desktop keyring (GNOME Keyring, KWallet and others) through the freedesktop.org
+// Secret Service API over D-Bus. Secrets are found by the attributes application=planespotter and key
+type KeyringStore struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+}
+
+// keyringSecret is a Secret Service secret, as sent over D-Bus
+type keyringSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// OpenKeyring connects to the Secret Service on the session bus and returns a KeyringStore using it, which must be
+// Closed when done with. Returns an error if there is no session bus or nothing on it provides the Secret Service
+func OpenKeyring() (*KeyringStore, error) {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("the keyring isn't available: %w", err)
+	}
+
+	// The plain algorithm sends secrets unencrypted, but only over the user's own session bus
+	k := &KeyringStore{conn: conn}
+	var output dbus.Variant
+	err = k.service().Call("org.freedesktop.Secret.Service.OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &k.session)
+	if err != nil {
+		return nil, fmt.Errorf("the keyring isn't available: %w", err)
+	}
+
+	return k, nil
+}
+
+// Close closes the Secret Service session. The session bus connection is shared, so it is left open
+func (k *KeyringStore) Close() error {
+	return k.conn.Object(secretService, k.session).Call("org.freedesktop.Secret.Session.Close", 0).Err
+}
+
+// Get takes a key and returns its secret from the keyring, unlocking it if needed, or ErrNotFound if it isn't there
+func (k *KeyringStore) Get(key string) (string, error) {
+	item, err := k.find(key)
+	if err != nil {
+		return "", err
+	}
+
+	var secret keyringSecret
+	if err := k.conn.Object(secretService, item).Call("org.freedesktop.Secret.Item.GetSecret", 0, k.session).Store(&secret); err != nil {
+		return "", err
+	}
+
+	return string(secret.Value), nil
+}
+
+// Set takes a key and a secret and saves it in the default keyring collection, replacing any already there
+func (k *KeyringStore) Set(key, value string) error {
+	var collection dbus.ObjectPath
+	if err := k.service().Call("org.freedesktop.Secret.Service.ReadAlias", 0, "default").Store(&collection); err != nil {
+		return err
+	}
+	if collection == noPrompt {
+		return errors.New("the keyring has no default collection")
+	}
+	if err := k.unlock(collection); err != nil {
+		return err
+	}
+
+	properties := map[string]dbus.Variant{
+		"org.freedesktop.Secret.Item.Label":      dbus.MakeVariant("Planespotter " + key),
+		"org.freedesktop.Secret.Item.Attributes": dbus.MakeVariant(attributes(key)),
+	}
+	secret := keyringSecret{Session: k.session, Value: []byte(value), ContentType: "text/plain"}
+	var item, prompt dbus.ObjectPath
+	err := k.conn.Object(secretService, collection).Call("org.freedesktop.Secret.Collection.CreateItem", 0, properties, secret, true).Store(&item, &prompt)
+	if err != nil {
+		return err
+	}
+
+	return k.prompt(prompt)
+}
+
+// Delete takes a key and removes its secret from the keyring, or returns ErrNotFound if it isn't there
+func (k *KeyringStore) Delete(key string) error {
+	item, err := k.find(key)
+	if err != nil {
+		return err
+	}
+
+	var prompt dbus.ObjectPath
+	if err := k.conn.Object(secretService, item).Call("org.freedesktop.Secret.Item.Delete", 0).Store(&prompt); err != nil {
+		return err
+	}
+
+	return k.prompt(prompt)
+}
+
+// service returns the Secret Service object
+func (k *KeyringStore) service() dbus.BusObject {
+	return k.conn.Object(secretService, servicePath)
+}
+
+// attributes takes a key and returns the attributes its secret is saved with
+func attributes(key string) map[string]string {
+	return map[string]string{"application": "planespotter", "key": key}
+}
+
+// find takes a key and returns the keyring item holding its secret, unlocking it if needed
+// Returns ErrNotFound if there isn't one
+func (k *KeyringStore) find(key string) (dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := k.service().Call("org.freedesktop.Secret.Service.SearchItems", 0, attributes(key)).Store(&unlocked, &locked); err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(unlocked) > 0:
+		return unlocked[0], nil
+	case len(locked) > 0:
+		return locked[0], k.unlock(locked[0])
+	default:
+		return "", ErrNotFound
+	}
+}
+
+// unlock takes a keyring item or collection and unlocks it, asking the user if the keyring needs them to
+func (k *KeyringStore) unlock(object dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := k.service().Call("org.freedesktop.Secret.Service.Unlock", 0, []dbus.ObjectPath{object}).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+
+	return k.prompt(prompt)
+}
+
+// prompt takes a Secret Service prompt, shows it to the user and waits for them to complete it, so it mustn't be
+// called on the UI goroutine. Returns an error if they dismiss it or don't answer within promptTimeout
+func (k *KeyringStore) prompt(prompt dbus.ObjectPath) error {
+	if prompt == noPrompt || prompt == "" {
+		return nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface("org.freedesktop.Secret.Prompt"),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := k.conn.AddMatchSignal(match...); err != nil {
+		return err
+	}
+	defer k.conn.RemoveMatchSignal(match...)
+
+	signals := make(chan *dbus.Signal, 1)
+	k.conn.Signal(signals)
+	defer k.conn.RemoveSignal(signals)
+
+	if err := k.conn.Object(secretService, prompt).Call("org.freedesktop.Secret.Prompt.Prompt", 0, "").Err; err != nil {
+		return err
+	}
+
+	timeout := time.After(promptTimeout)
+	for {
+		select {
+		case s := <-signals:
+			if s.Path != prompt || s.Name != "org.freedesktop.Secret.Prompt.Completed" {
+				continue
+			}
+			if len(s.Body) > 0 && s.Body[0] == true {
+				return errors.New("the keyring prompt was dismissed")
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for the keyring to be unlocked")
+		}
+	}
+}
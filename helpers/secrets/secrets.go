@@ -0,0 +1,206 @@
+// Package secrets keeps the OpenSky password and client secret out of the save, in the desktop keyring over D-Bus,
+// a passphrase encrypted file or environment variables, behind one Store interface.
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"planespotter/helpers/types"
+	"strings"
+)
+
+const Keyring = "keyring"
+const File = "file"
+const Env = "env"
+
+// Backends lists the places secrets can be kept
+var Backends = []string{Keyring, File, Env}
+
+// PasswordKey and ClientSecretKey are the keys the OpenSky password and API client secret are stored under
+const PasswordKey = "opensky-password"
+const ClientSecretKey = "opensky-client-secret"
+
+// DefaultFile is the encrypted secrets file used when the config doesn't name one
+const DefaultFile = "secrets.enc"
+
+// PassphraseVariable is the environment variable the encrypted file's passphrase can be given in
+const PassphraseVariable = "PLANESPOTTER_PASSPHRASE"
+
+// ErrNotFound is returned when a Store has nothing under a key
+var ErrNotFound = errors.New("secret not found")
+
+// ErrReadOnly is returned when a Store can't be written to, such as environment variables
+var ErrReadOnly = errors.New("secrets can't be saved here")
+
+// Store keeps secrets by key
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+// EnvStore reads secrets from environment variables named after the key, e.g. PLANESPOTTER_OPENSKY_PASSWORD
+// It can't be written to
+type EnvStore struct {
+	Getenv func(string) string
+}
+
+// Variable takes a key and returns the environment variable it is read from
+func Variable(key string) string {
+	return "PLANESPOTTER_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// Get takes a key and returns the value of its environment variable, or ErrNotFound if it is blank
+func (e EnvStore) Get(key string) (string, error) {
+	getenv := e.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	if value := getenv(Variable(key)); value != "" {
+		return value, nil
+	}
+
+	return "", ErrNotFound
+}
+
+// Set returns ErrReadOnly, as environment variables are set outside planespotter
+func (EnvStore) Set(key, value string) error { return ErrReadOnly }
+
+// Delete returns ErrReadOnly, as environment variables are set outside planespotter
+func (EnvStore) Delete(key string) error { return ErrReadOnly }
+
+// Layered reads a secret from each Store in turn until one has it, and writes to the last
+// so environment variables can override a keyring or file
+type Layered []Store
+
+// Get takes a key and returns the value from the first Store that has it, or ErrNotFound if none do
+func (l Layered) Get(key string) (string, error) {
+	for _, s := range l {
+		value, err := s.Get(key)
+		if !errors.Is(err, ErrNotFound) {
+			return value, err
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// Set takes a key and value and saves it in the last Store
+func (l Layered) Set(key, value string) error {
+	if len(l) == 0 {
+		return ErrReadOnly
+	}
+	return l[len(l)-1].Set(key, value)
+}
+
+// Delete takes a key and removes it from the last Store
+func (l Layered) Delete(key string) error {
+	if len(l) == 0 {
+		return ErrReadOnly
+	}
+	return l[len(l)-1].Delete(key)
+}
+
+// Open takes the Secrets settings and the passphrase for an encrypted file, and returns the Store they describe,
+// with environment variables read first. With no backend the secrets are in the save, and the Store is empty
+// The Store should be Closed when done with. Returns an error if the backend is unknown or can't be reached
+func Open(settings types.Secrets, passphrase string) (Store, error) {
+	switch settings.Backend {
+	case Keyring:
+		k, err := OpenKeyring()
+		if err != nil {
+			return nil, err
+		}
+		return Layered{EnvStore{}, k}, nil
+	case File:
+		if passphrase == "" {
+			return nil, errors.New("the secrets file needs a passphrase")
+		}
+		path := settings.File
+		if path == "" {
+			path = DefaultFile
+		}
+		return Layered{EnvStore{}, NewFileStore(path, passphrase)}, nil
+	case Env:
+		return EnvStore{}, nil
+	case "":
+		// Kept in the save, so there is nowhere else to look
+		return Layered{}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q, expected %v", settings.Backend, strings.Join(Backends, ", "))
+	}
+}
+
+// Close takes a Store from Open and closes anything it holds open, such as a keyring session
+func Close(store Store) error {
+	switch s := store.(type) {
+	case Layered:
+		var errs []error
+		for _, layer := range s {
+			errs = append(errs, Close(layer))
+		}
+		return errors.Join(errs...)
+	case io.Closer:
+		return s.Close()
+	default:
+		return nil
+	}
+}
+
+// Load takes a Store and the ApiAuth from the save, and returns it with the password and client secret from the Store
+// A secret the Store doesn't have is left as it is. Returns an error if the Store can't be read
+func Load(store Store, auth types.ApiAuth) (types.ApiAuth, error) {
+	for key, value := range fields(&auth) {
+		secret, err := store.Get(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return auth, fmt.Errorf("reading %v: %w", key, err)
+		}
+		*value = secret
+	}
+
+	return auth, nil
+}
+
+// Save takes a Store and an ApiAuth, saves its password and client secret in the Store, deleting any that are blank,
+// and returns the ApiAuth without them ready for the save. Secrets the Store already has are left alone, so
+// saving works with read only stores when nothing has changed. Returns an error if the Store can't be written to
+func Save(store Store, auth types.ApiAuth) (types.ApiAuth, error) {
+	for key, value := range fields(&auth) {
+		current, err := store.Get(key)
+		if (err == nil && current == *value) || (errors.Is(err, ErrNotFound) && *value == "") {
+			continue
+		}
+
+		if *value == "" {
+			err = store.Delete(key)
+		} else {
+			err = store.Set(key, *value)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return auth, fmt.Errorf("saving %v: %w", key, err)
+		}
+	}
+
+	return Strip(auth), nil
+}
+
+// Strip takes an ApiAuth and returns it without its password and client secret
+func Strip(auth types.ApiAuth) types.ApiAuth {
+	auth.Password = ""
+	auth.ClientSecret = ""
+	return auth
+}
+
+// fields takes an ApiAuth and returns pointers to its secrets by key
+func fields(auth *types.ApiAuth) map[string]*string {
+	return map[string]*string{
+		PasswordKey:     &auth.Password,
+		ClientSecretKey: &auth.ClientSecret,
+	}
+}
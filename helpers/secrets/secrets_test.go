@@ -0,0 +1,165 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPbkdf2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256, as checked against other implementations
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+		expected   string
+	}{
+		{password: "password", salt: "salt", iterations: 1, keyLen: 32, expected: "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{password: "password", salt: "salt", iterations: 2, keyLen: 32, expected: "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{password: "password", salt: "salt", iterations: 4096, keyLen: 32, expected: "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+		{password: "passwordPASSWORDpassword", salt: "saltSALTsaltSALTsaltSALTsaltSALTsalt", iterations: 4096, keyLen: 40, expected: "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, hex.EncodeToString(pbkdf2([]byte(test.password), []byte(test.salt), test.iterations, test.keyLen)))
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	f := NewFileStore(path, "correct horse battery staple")
+	f.Iterations = 1000
+
+	_, err := f.Get(PasswordKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, f.Set(PasswordKey, "fluff"))
+	assert.NoError(t, f.Set(ClientSecretKey, "s3cret"))
+	value, err := f.Get(PasswordKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "fluff", value)
+
+	// Nothing readable is left in the file, and only the user can read it
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "fluff")
+	assert.NotContains(t, string(content), PasswordKey)
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.NoError(t, f.Delete(PasswordKey))
+	_, err = f.Get(PasswordKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, f.Delete(PasswordKey), ErrNotFound)
+
+	_, err = NewFileStore(path, "wrong").Get(ClientSecretKey)
+	assert.ErrorIs(t, err, ErrPassphrase)
+}
+
+func TestEnvStore(t *testing.T) {
+	env := EnvStore{Getenv: func(name string) string {
+		return map[string]string{"PLANESPOTTER_OPENSKY_PASSWORD": "from-env"}[name]
+	}}
+
+	value, err := env.Get(PasswordKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+	_, err = env.Get(ClientSecretKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, env.Set(PasswordKey, "fluff"), ErrReadOnly)
+	assert.Equal(t, "PLANESPOTTER_OPENSKY_CLIENT_SECRET", Variable(ClientSecretKey))
+}
+
+func TestLayered(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "secrets.enc"), "passphrase")
+	f.Iterations = 1000
+	env := EnvStore{Getenv: func(name string) string {
+		return map[string]string{"PLANESPOTTER_OPENSKY_PASSWORD": "from-env"}[name]
+	}}
+	l := Layered{env, f}
+
+	// Environment variables come first, and everything is written to the file
+	assert.NoError(t, l.Set(PasswordKey, "from-file"))
+	assert.NoError(t, l.Set(ClientSecretKey, "s3cret"))
+	value, _ := l.Get(PasswordKey)
+	assert.Equal(t, "from-env", value)
+	value, _ = l.Get(ClientSecretKey)
+	assert.Equal(t, "s3cret", value)
+	value, _ = f.Get(PasswordKey)
+	assert.Equal(t, "from-file", value)
+}
+
+// closingStore is an EnvStore that counts how many times it is closed, like a keyring session
+type closingStore struct {
+	EnvStore
+	closed int
+}
+
+func (c *closingStore) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	session := &closingStore{}
+	assert.NoError(t, Close(Layered{EnvStore{}, session}))
+	assert.Equal(t, 1, session.closed)
+	assert.NoError(t, Close(EnvStore{}))
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	f := NewFileStore(filepath.Join(t.TempDir(), "secrets.enc"), "passphrase")
+	f.Iterations = 1000
+
+	auth := types.ApiAuth{Username: "blah", Password: "fluff", ClientId: "spotter-api-client", ClientSecret: "s3cret"}
+	stripped, err := Save(f, auth)
+	assert.NoError(t, err)
+	assert.Equal(t, types.ApiAuth{Username: "blah", ClientId: "spotter-api-client"}, stripped)
+
+	loaded, err := Load(f, stripped)
+	assert.NoError(t, err)
+	assert.Equal(t, auth, loaded)
+
+	// A blank secret is deleted
+	_, err = Save(f, types.ApiAuth{Username: "blah", Password: "fluff"})
+	assert.NoError(t, err)
+	_, err = f.Get(ClientSecretKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Saving what a read only store already has is fine, changing it isn't
+	env := EnvStore{Getenv: func(name string) string {
+		return map[string]string{"PLANESPOTTER_OPENSKY_PASSWORD": "fluff"}[name]
+	}}
+	_, err = Save(env, types.ApiAuth{Username: "blah", Password: "fluff"})
+	assert.NoError(t, err)
+	_, err = Save(env, types.ApiAuth{Username: "blah", Password: "changed"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open(types.Secrets{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, Layered{}, s)
+	_, err = s.Get(PasswordKey)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	s, err = Open(types.Secrets{Backend: Env}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, EnvStore{}, s)
+
+	_, err = Open(types.Secrets{Backend: File}, "")
+	assert.EqualError(t, err, "the secrets file needs a passphrase")
+
+	s, err = Open(types.Secrets{Backend: File, File: "my-secrets.enc"}, "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-secrets.enc", s.(Layered)[1].(*FileStore).Path)
+
+	_, err = Open(types.Secrets{Backend: "vault"}, "")
+	assert.EqualError(t, err, `unknown secrets backend "vault", expected keyring, file, env`)
+}
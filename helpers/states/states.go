@@ -0,0 +1,182 @@
+// Package states reads OpenSky state vector responses: it drops states that haven't been heard from recently,
+// remembers the time of each query's latest response, and asks for aircraft categories and watchlisted aircraft.
+
+package states
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxAgeSeconds is how long since a plane was last heard from before its state is dropped, when no other
+// age is set. OpenSky keeps states for up to 5 minutes, by when the plane could be 50 km away
+const DefaultMaxAgeSeconds = 60
+
+// ErrUnchanged is returned with the states of a response no newer than the last one for the same query, as there
+// is nothing new in it
+var ErrUnchanged = errors.New("states unchanged since the last response")
+
+// Watchlist is the Area of planes found by the watchlist query rather than in a spot area. It is saved as it is,
+// whatever the language, and shown with AreaLabel
+const Watchlist = "area.watchlist"
+
+// lastContact and category are the positions of the last contact time and the aircraft category in a state
+const lastContact = 4
+const category = 17
+
+// icao24Pattern matches an ICAO 24-bit address as 6 hex digits
+var icao24Pattern = regexp.MustCompile(`^[0-9a-f]{6}$`)
+
+// categories are the names of OpenSky's aircraft categories, by number. Those without a name mean the category
+// isn't known
+var categories = []string{
+	"", "", "light", "small", "large", "high_vortex_large", "heavy", "high_performance", "rotorcraft", "glider",
+	"lighter_than_air", "parachutist", "ultralight", "", "uav", "space", "emergency_vehicle", "service_vehicle",
+	"point_obstacle", "cluster_obstacle", "line_obstacle",
+}
+
+// MaxAge takes the States settings and returns how long since a plane was last heard from before its state is dropped
+func MaxAge(s types.States) time.Duration {
+	if s.MaxAgeSeconds > 0 {
+		return time.Duration(s.MaxAgeSeconds) * time.Second
+	}
+
+	return DefaultMaxAgeSeconds * time.Second
+}
+
+// Params takes the query parameters of a request for states and the States settings, and adds extended=1 to ask for
+// aircraft categories if they are wanted
+func Params(params url.Values, s types.States) {
+	if s.Extended {
+		params.Set("extended", "1")
+	}
+}
+
+// WatchlistParams takes the States settings and returns the query parameters asking for only the watchlisted
+// aircraft, wherever they are, or nil if there is no watchlist
+func WatchlistParams(s types.States) url.Values {
+	if len(s.Icao24) == 0 {
+		return nil
+	}
+
+	params := url.Values{"icao24": s.Icao24}
+	Params(params, s)
+	return params
+}
+
+// ParseIcao24 takes text with ICAO 24-bit addresses separated by commas, spaces or new lines, and returns them
+// in lower case as OpenSky expects. Returns an error naming the first one that isn't 6 hex digits
+func ParseIcao24(text string) ([]string, error) {
+	var addresses []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }) {
+		address := strings.ToLower(strings.TrimSpace(field))
+		if !icao24Pattern.MatchString(address) {
+			return addresses, fmt.Errorf("%q: expected an icao24 address of 6 hex digits", field)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// Fresh takes a Result and a maximum age, and returns its states last heard from no more than maxAge before the
+// time of the response. States without a last contact time are kept, as are all of them if the response has no time
+func Fresh(r types.Result, maxAge time.Duration) [][]interface{} {
+	if r.Time == 0 {
+		return r.PlaneResults
+	}
+
+	oldest := float64(r.Time) - maxAge.Seconds()
+	var fresh [][]interface{}
+	for _, state := range r.PlaneResults {
+		if len(state) > lastContact {
+			if contact, ok := formatters.ParseFloat(state[lastContact]); ok && contact < oldest {
+				continue
+			}
+		}
+		fresh = append(fresh, state)
+	}
+
+	return fresh
+}
+
+// Category takes a state and returns the name of its aircraft category, e.g. "large", or "" if it isn't known or the
+// state wasn't asked for with extended=1. The name is kept the same whatever the language, so it can be saved
+// and grouped by, and is shown with CategoryLabel
+func Category(state []interface{}) string {
+	if len(state) <= category {
+		return ""
+	}
+	c, ok := formatters.ParseFloat(state[category])
+	if !ok || c < 0 || int(c) >= len(categories) {
+		return ""
+	}
+
+	return categories[int(c)]
+}
+
+// CategoryLabel takes the name of an aircraft category and returns it in the current language, or "" if there is none
+func CategoryLabel(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	return i18n.T("category." + name)
+}
+
+// AreaLabel takes the Area a plane was found in and returns it to show in the current language. Areas are named
+// after profiles, so only the Watchlist is translated
+func AreaLabel(area string) string {
+	if area == Watchlist {
+		return i18n.T(Watchlist)
+	}
+
+	return area
+}
+
+// Feed remembers the time of the latest response to each query, so a response no newer than the last one can be
+// spotted. It is safe to use from more than one goroutine
+type Feed struct {
+	maxAge time.Duration
+	mu     sync.Mutex
+	latest map[string]int64
+}
+
+// NewFeed takes the maximum age of a state and returns an empty Feed
+func NewFeed(maxAge time.Duration) *Feed {
+	return &Feed{maxAge: maxAge, latest: make(map[string]int64)}
+}
+
+// Update takes a query url and the Result of the query, and returns the Fresh states in it and whether the
+// response is newer than the last one for the query. A response with no time is always taken as newer
+func (f *Feed) Update(query string, r types.Result) ([][]interface{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	newer := r.Time == 0 || r.Time > f.latest[query]
+	if r.Time > f.latest[query] {
+		f.latest[query] = r.Time
+	}
+
+	return Fresh(r, f.maxAge), newer
+}
+
+// Latest takes a query url and returns the time of the latest response to it, or the zero time if there hasn't been one
+func (f *Feed) Latest(query string) time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.latest[query] == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(f.latest[query], 0)
+}
@@ -0,0 +1,121 @@
+package states
+
+import (
+	"net/url"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxAge(t *testing.T) {
+	assert.Equal(t, 60*time.Second, MaxAge(types.States{}))
+	assert.Equal(t, 30*time.Second, MaxAge(types.States{MaxAgeSeconds: 30}))
+}
+
+func TestParams(t *testing.T) {
+	params := url.Values{"lamin": {"51.0000"}}
+	Params(params, types.States{})
+	assert.Equal(t, "lamin=51.0000", params.Encode())
+
+	// The watchlist is its own query, so it never narrows the spot area's
+	Params(params, types.States{Extended: true, Icao24: []string{"4007f5", "400a0b"}})
+	assert.Equal(t, "extended=1&lamin=51.0000", params.Encode())
+}
+
+func TestWatchlistParams(t *testing.T) {
+	assert.Nil(t, WatchlistParams(types.States{Extended: true}))
+	assert.Equal(t, "icao24=4007f5&icao24=400a0b", WatchlistParams(types.States{Icao24: []string{"4007f5", "400a0b"}}).Encode())
+	assert.Equal(t, "extended=1&icao24=4007f5", WatchlistParams(types.States{Extended: true, Icao24: []string{"4007f5"}}).Encode())
+}
+
+func TestParseIcao24(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+		err      string
+	}{
+		{"", nil, ""},
+		{"4007F5", []string{"4007f5"}, ""},
+		{"4007f5, 400a0b\n3c6444", []string{"4007f5", "400a0b", "3c6444"}, ""},
+		{"4007f5, BAW12", []string{"4007f5"}, `"BAW12": expected an icao24 address of 6 hex digits`},
+	}
+
+	for _, test := range tests {
+		addresses, err := ParseIcao24(test.text)
+		assert.Equal(t, test.expected, addresses, test.text)
+		if test.err == "" {
+			assert.NoError(t, err, test.text)
+		} else {
+			assert.EqualError(t, err, test.err, test.text)
+		}
+	}
+}
+
+func TestFresh(t *testing.T) {
+	recent := []interface{}{"4007f5", "BAW12", "United Kingdom", 1760875195.0, 1760875198.0}
+	stale := []interface{}{"400a0b", "EZY34", "United Kingdom", 1760875000.0, 1760875000.0}
+	unknown := []interface{}{"3c6444", "DLH5", "Germany", nil, nil}
+	r := types.Result{Time: 1760875200, PlaneResults: [][]interface{}{recent, stale, unknown}}
+
+	assert.Equal(t, [][]interface{}{recent, unknown}, Fresh(r, time.Minute))
+	assert.Equal(t, r.PlaneResults, Fresh(r, 5*time.Minute))
+
+	r.Time = 0
+	assert.Equal(t, r.PlaneResults, Fresh(r, time.Minute))
+}
+
+func TestCategory(t *testing.T) {
+	state := make([]interface{}, 18)
+	assert.Equal(t, "", Category(state))
+	state[17] = 6.0
+	assert.Equal(t, "heavy", Category(state))
+	state[17] = 1.0
+	assert.Equal(t, "", Category(state))
+	state[17] = 21.0
+	assert.Equal(t, "", Category(state))
+	assert.Equal(t, "", Category(state[:17]))
+}
+
+func TestAreaLabel(t *testing.T) {
+	i18n.Set(i18n.French)
+	defer i18n.Set(i18n.English)
+
+	assert.Equal(t, "Liste de suivi", AreaLabel(Watchlist))
+	assert.Equal(t, "Home", AreaLabel("Home"))
+}
+
+func TestCategoryLabel(t *testing.T) {
+	assert.Equal(t, "", CategoryLabel(""))
+	assert.Equal(t, i18n.T("category.high_vortex_large"), CategoryLabel("high_vortex_large"))
+
+	i18n.Set(i18n.French)
+	defer i18n.Set(i18n.English)
+	assert.Equal(t, i18n.T("category.rotorcraft"), CategoryLabel("rotorcraft"))
+	assert.NotEqual(t, "category.rotorcraft", CategoryLabel("rotorcraft"))
+}
+
+func TestFeed(t *testing.T) {
+	feed := NewFeed(time.Minute)
+	query := "https://opensky-network.org/api/states/all?lamin=51"
+	assert.True(t, feed.Latest(query).IsZero())
+
+	state := []interface{}{"4007f5", "BAW12", "United Kingdom", 1760875195.0, 1760875198.0}
+	states, newer := feed.Update(query, types.Result{Time: 1760875200, PlaneResults: [][]interface{}{state}})
+	assert.True(t, newer)
+	assert.Len(t, states, 1)
+	assert.Equal(t, time.Unix(1760875200, 0), feed.Latest(query))
+
+	// The same snapshot again isn't newer, and is judged against its own time rather than the latest
+	_, newer = feed.Update(query, types.Result{Time: 1760875200})
+	assert.False(t, newer)
+	states, newer = feed.Update(query, types.Result{Time: 1760875400, PlaneResults: [][]interface{}{state}})
+	assert.True(t, newer)
+	assert.Empty(t, states)
+
+	// Each query is tracked on its own
+	_, newer = feed.Update("https://opensky-network.org/api/states/all?lamin=52", types.Result{Time: 1760875200})
+	assert.True(t, newer)
+}
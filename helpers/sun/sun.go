@@ -0,0 +1,142 @@
+// Package sun works out the sun's position, sunrise and sunset for the observer offline, using NOAA's solar
+// calculations, so spotting can depend on daylight and warn when a plane is against the sun.
+
+package sun
+
+import (
+	"math"
+	"planespotter/helpers/types"
+	"time"
+)
+
+const Day = "day"
+const Night = "night"
+
+const Always = "always"
+const DaylightOnly = "daylight only"
+const NightOnly = "night only"
+
+// Rules lists when notifications can be limited to
+var Rules = []string{Always, DaylightOnly, NightOnly}
+
+// horizonDeg is the sun's elevation at sunrise and sunset, allowing for refraction and the size of the sun's disc
+const horizonDeg = -0.833
+
+// backlitDeg is how close to the sun a plane has to be to be backlit
+const backlitDeg = 15.0
+
+// Position takes the observer's Position and a time, and returns the sun's azimuth in degrees clockwise from
+// true north, and its elevation in degrees above the horizon, not allowing for refraction
+func Position(observer types.Position, t time.Time) (float64, float64) {
+	declination, eqTime := solar(julianCentury(t))
+
+	t = t.UTC()
+	minutes := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	trueSolarTime := math.Mod(minutes+eqTime+4*observer.Longitude, 1440)
+	hourAngle := toRadians(trueSolarTime/4 - 180)
+
+	lat, dec := toRadians(observer.Latitude), toRadians(declination)
+	elevation := math.Asin(math.Sin(lat)*math.Sin(dec) + math.Cos(lat)*math.Cos(dec)*math.Cos(hourAngle))
+	azimuth := math.Atan2(-math.Sin(hourAngle), math.Tan(dec)*math.Cos(lat)-math.Sin(lat)*math.Cos(hourAngle))
+
+	return math.Mod(toDegrees(azimuth)+360, 360), toDegrees(elevation)
+}
+
+// Times takes the observer's Position and a date, and returns the times of sunrise and sunset on that date
+// Returns false if the sun doesn't rise or doesn't set that day, as near the poles
+func Times(observer types.Position, date time.Time) (time.Time, time.Time, bool) {
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	declination, eqTime := solar(julianCentury(midnight.Add(12 * time.Hour)))
+
+	lat, dec := toRadians(observer.Latitude), toRadians(declination)
+	cosHourAngle := math.Cos(toRadians(90-horizonDeg))/(math.Cos(lat)*math.Cos(dec)) - math.Tan(lat)*math.Tan(dec)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	noon := 720 - 4*observer.Longitude - eqTime
+	halfDay := 4 * toDegrees(math.Acos(cosHourAngle))
+	sunrise := midnight.Add(time.Duration((noon - halfDay) * float64(time.Minute)))
+	sunset := midnight.Add(time.Duration((noon + halfDay) * float64(time.Minute)))
+
+	return sunrise.In(date.Location()), sunset.In(date.Location()), true
+}
+
+// IsDaylight takes the observer's Position and a time, and returns true if the sun is up
+func IsDaylight(observer types.Position, t time.Time) bool {
+	_, elevation := Position(observer, t)
+	return elevation > horizonDeg
+}
+
+// Period takes the observer's Position and a time, and returns Day or Night
+func Period(observer types.Position, t time.Time) string {
+	if IsDaylight(observer, t) {
+		return Day
+	}
+
+	return Night
+}
+
+// Allowed takes a rule (one of Rules), the observer's Position and a time, and returns true if the rule allows
+// notifications at that time. A blank or unknown rule is the same as Always
+func Allowed(rule string, observer types.Position, t time.Time) bool {
+	switch rule {
+	case DaylightOnly:
+		return IsDaylight(observer, t)
+	case NightOnly:
+		return !IsDaylight(observer, t)
+	default:
+		return true
+	}
+}
+
+// Backlit takes the observer's Position, the LookAngles to a plane and a time, and returns true if the sun is up
+// and close behind the plane from the observer's point of view, so photos of it would be into the sun
+func Backlit(observer types.Position, look types.LookAngles, t time.Time) bool {
+	azimuth, elevation := Position(observer, t)
+	if elevation <= 0 || look.SlantRangeKm == 0 {
+		return false
+	}
+
+	// Angle between the two directions, using the spherical law of cosines
+	el1, el2 := toRadians(elevation), toRadians(look.ElevationDeg)
+	cosSeparation := math.Sin(el1)*math.Sin(el2) + math.Cos(el1)*math.Cos(el2)*math.Cos(toRadians(azimuth-look.AzimuthDeg))
+	return toDegrees(math.Acos(math.Max(-1, math.Min(1, cosSeparation)))) < backlitDeg
+}
+
+// julianCentury takes a time and returns the number of Julian centuries since the J2000 epoch
+func julianCentury(t time.Time) float64 {
+	julianDay := float64(t.Unix())/86400 + 2440587.5
+	return (julianDay - 2451545) / 36525
+}
+
+// solar takes a Julian century and returns the sun's declination in degrees and the equation of time in minutes
+func solar(jc float64) (float64, float64) {
+	meanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnomaly := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccentricity := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+
+	m := toRadians(meanAnomaly)
+	centre := math.Sin(m)*(1.914602-jc*(0.004817+0.000014*jc)) + math.Sin(2*m)*(0.019993-0.000101*jc) + math.Sin(3*m)*0.000289
+	omega := toRadians(125.04 - 1934.136*jc)
+	apparentLong := meanLong + centre - 0.00569 - 0.00478*math.Sin(omega)
+
+	meanObliquity := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliquity := toRadians(meanObliquity + 0.00256*math.Cos(omega))
+	declination := toDegrees(math.Asin(math.Sin(obliquity) * math.Sin(toRadians(apparentLong))))
+
+	y := math.Pow(math.Tan(obliquity/2), 2)
+	l := toRadians(meanLong)
+	eqTime := 4 * toDegrees(y*math.Sin(2*l)-2*eccentricity*math.Sin(m)+4*eccentricity*y*math.Sin(m)*math.Cos(2*l)-
+		0.5*y*y*math.Sin(4*l)-1.25*eccentricity*eccentricity*math.Sin(2*m))
+
+	return declination, eqTime
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
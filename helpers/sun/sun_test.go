@@ -0,0 +1,76 @@
+package sun
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var london = types.Position{Latitude: 51.5, Longitude: -0.1}
+
+func TestPosition(t *testing.T) {
+	// Around solar noon on the summer solstice the sun is due south, 90 - 51.5 + 23.44 degrees up
+	azimuth, elevation := Position(london, time.Date(2026, 6, 21, 12, 2, 0, 0, time.UTC))
+	assert.InDelta(t, 180, azimuth, 1)
+	assert.InDelta(t, 61.9, elevation, 0.2)
+
+	// Mid afternoon in October it is in the south west
+	azimuth, elevation = Position(london, time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC))
+	assert.InDelta(t, 216.0, azimuth, 0.5)
+	assert.InDelta(t, 22.2, elevation, 0.5)
+
+	_, elevation = Position(london, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	assert.Less(t, elevation, -30.0)
+}
+
+func TestTimes(t *testing.T) {
+	sunrise, sunset, ok := Times(london, time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, "03:43", sunrise.Format("15:04"))
+	assert.Equal(t, "20:21", sunset.Format("15:04"))
+
+	// Sunrise and sunset are given in the date's time zone
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	sunrise, _, _ = Times(types.Position{Latitude: 35.68, Longitude: 139.77}, time.Date(2026, 3, 20, 0, 0, 0, 0, tokyo))
+	assert.Equal(t, "05:44", sunrise.Format("15:04"))
+
+	_, _, ok = Times(types.Position{Latitude: 78, Longitude: 15}, time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok, "The sun doesn't set in summer in Svalbard")
+}
+
+func TestAllowed(t *testing.T) {
+	noon := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule     string
+		t        time.Time
+		expected bool
+	}{
+		{rule: Always, t: midnight, expected: true},
+		{rule: "", t: midnight, expected: true},
+		{rule: DaylightOnly, t: noon, expected: true},
+		{rule: DaylightOnly, t: midnight, expected: false},
+		{rule: NightOnly, t: noon, expected: false},
+		{rule: NightOnly, t: midnight, expected: true},
+	}
+
+	for _, test := range tests {
+		res := Allowed(test.rule, london, test.t)
+		assert.Equal(t, test.expected, res, "Allowed(%q, %v)", test.rule, test.t)
+	}
+
+	assert.Equal(t, Day, Period(london, noon))
+	assert.Equal(t, Night, Period(london, midnight))
+}
+
+func TestBacklit(t *testing.T) {
+	afternoon := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+
+	assert.True(t, Backlit(london, types.LookAngles{AzimuthDeg: 210, ElevationDeg: 25, SlantRangeKm: 5}, afternoon))
+	assert.False(t, Backlit(london, types.LookAngles{AzimuthDeg: 30, ElevationDeg: 25, SlantRangeKm: 5}, afternoon))
+	assert.False(t, Backlit(london, types.LookAngles{AzimuthDeg: 210, ElevationDeg: 60, SlantRangeKm: 5}, afternoon))
+	assert.False(t, Backlit(london, types.LookAngles{AzimuthDeg: 210, ElevationDeg: 25, SlantRangeKm: 5}, afternoon.Add(-14*time.Hour)))
+}
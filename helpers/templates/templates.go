@@ -0,0 +1,145 @@
+// Package templates renders the title and message of plane notifications from user defined text/templates,
+// with the plane's fields, where it is from the observer and the progress counters available to them.
+
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/states"
+	"planespotter/helpers/types"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTitle is the notification title used when no title template is set
+const DefaultTitle = `{{.T "notify.spotted_title"}}`
+
+// DefaultMessage is the notification message used when no message template is set
+// Its words come from the current language's catalogue
+const DefaultMessage = `{{if .Area}}📍 {{.Area}} 
+{{end}}{{.Callsign}} 
+ ↑ {{.Baro_Altitude}} → {{.Velocity}} 🧭 {{.True_Track}} 
+{{.T "notify.total_seen" (.Number .TotalSeen 0)}}{{if .Look.SlantRangeKm}} 
+👀 {{.T "notify.look" (compass .Look.AzimuthDeg) (.Number .Look.ElevationDeg 0) (.Distance .Look.SlantRangeKm)}}{{end}}{{if .Backlit}} 
+☀️ {{.T "notify.backlit"}}{{end}}`
+
+// Data is everything a notification template can use. The plane's fields are available directly, e.g.
+// {{.Callsign}} or {{.State.Latitude}}, along with its distance and bearing from the observer and the counters
+// Distances are in km, use {{.Distance .DistanceKm}} to show one in the configured Units
+type Data struct {
+	types.PlaneInfo
+	Units       types.Units
+	DistanceKm  float64
+	Bearing     float64
+	Backlit     bool
+	Profile     string
+	TotalSeen   int
+	ProfileSeen int
+	Time        time.Time
+}
+
+// funcs are the extra functions available in notification templates
+var funcs = template.FuncMap{
+	"compass": formatters.FormatCompass,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"km": func(km float64) string {
+		return formatters.FormatDistance(km, formatters.Km)
+	},
+	"clock": i18n.Clock,
+	"date":  i18n.Date,
+}
+
+// Distance takes a distance in km and returns it formatted in the Data's distance unit, e.g. "1.9 km"
+func (d Data) Distance(km float64) string {
+	return formatters.FormatDistance(km, d.Units.Distance)
+}
+
+// T takes a catalogue key and any arguments for it, and returns the message in the current language
+func (d Data) T(key string, args ...interface{}) string {
+	return i18n.T(key, args...)
+}
+
+// Number takes a number and how many decimal places to show, and returns it written the current language's way
+// It accepts ints too, so counters like {{.Number .TotalSeen 0}} can be used
+func (d Data) Number(value interface{}, decimals int) string {
+	switch v := value.(type) {
+	case int:
+		return i18n.Number(float64(v), decimals)
+	case float64:
+		return i18n.Number(v, decimals)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Render takes the MessageTemplates and the Data for a plane, and returns the notification's title and message
+// A blank template uses the default, and the plane's area and category are shown in the current language
+// Returns an error if either template can't be parsed or run
+func Render(templates types.MessageTemplates, data Data) (string, string, error) {
+	data.Area = states.AreaLabel(data.Area)
+	data.Category = states.CategoryLabel(data.Category)
+
+	title, err := render("title", templates.Title, DefaultTitle, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	message, err := render("message", templates.Message, DefaultMessage, data)
+	if err != nil {
+		return "", "", err
+	}
+
+	return title, message, nil
+}
+
+// Sample returns Data for a made up plane, to preview templates with
+func Sample() Data {
+	return Data{
+		PlaneInfo: types.PlaneInfo{
+			Icao24:        "4007f5",
+			Callsign:      "BAW12",
+			Baro_Altitude: "3,500 ft",
+			On_Ground:     "false",
+			Velocity:      "180 kts",
+			True_Track:    "268°",
+			Vertical_Rate: "-688 ft/min",
+			Area:          "Home",
+			Category:      "large",
+			Look:          types.LookAngles{AzimuthDeg: 45, ElevationDeg: 35, SlantRangeKm: 1.9},
+			State: types.StateVector{
+				Has_Position: true, Latitude: 51.51, Longitude: -0.09, Has_Baro_Altitude: true, Baro_Altitude: 1067,
+				Has_Velocity: true, Velocity: 92.7, True_Track: 268, Has_Vertical_Rate: true, Vertical_Rate: -3.5,
+			},
+		},
+		DistanceKm:  1.6,
+		Bearing:     45,
+		Profile:     "Home",
+		TotalSeen:   42,
+		ProfileSeen: 12,
+		Time:        time.Date(2026, 10, 19, 14, 30, 0, 0, time.Local),
+	}
+}
+
+// render takes a name, template text, the default to use if it is blank and the Data, and returns the result
+func render(name, text, fallback string, data Data) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		text = fallback
+	}
+
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,82 @@
+package templates
+
+import (
+	"fmt"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/states"
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDefault(t *testing.T) {
+	data := Sample()
+
+	title, message, err := Render(types.MessageTemplates{}, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Plane Spotted!", title)
+	assert.Equal(t, "📍 Home \nBAW12 \n ↑ 3,500 ft → 180 kts 🧭 268° \nTotal seen: 42 \n👀 look NE, 35° up, 1.9 km away", message)
+
+	// The parts about the area and where to look are left out when there's nothing to say
+	data.Area = ""
+	data.Look = types.LookAngles{}
+	data.Backlit = true
+	_, message, _ = Render(types.MessageTemplates{}, data)
+	expected := fmt.Sprintf("%v \n ↑ %v → %v 🧭 %v \nTotal seen: %v", "BAW12", "3,500 ft", "180 kts", "268°", 42) + " \n☀️ backlit by the sun"
+	assert.Equal(t, expected, message)
+}
+
+func TestRender(t *testing.T) {
+	templates := types.MessageTemplates{
+		Title:   "{{.Callsign}} over {{.Profile}}",
+		Message: "{{lower .Icao24}} {{km .DistanceKm}} {{compass .Bearing}} at {{clock .Time}}, {{.State.Vertical_Rate}} m/s, {{.ProfileSeen}}/{{.TotalSeen}}",
+	}
+
+	title, message, err := Render(templates, Sample())
+	assert.NoError(t, err)
+	assert.Equal(t, "BAW12 over Home", title)
+	assert.Equal(t, "4007f5 1.6 km NE at 14:30, -3.5 m/s, 12/42", message)
+
+	_, _, err = Render(types.MessageTemplates{Title: "{{.Callsign"}, Sample())
+	assert.Error(t, err)
+
+	_, _, err = Render(types.MessageTemplates{Message: "{{.Registration}}"}, Sample())
+	assert.Error(t, err)
+}
+
+func TestRenderUnits(t *testing.T) {
+	data := Sample()
+	data.Units = types.Units{Distance: "nm"}
+
+	_, message, err := Render(types.MessageTemplates{Message: "{{.Distance .DistanceKm}}, {{.Vertical_Rate}}"}, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9 nm, -688 ft/min", message)
+
+	_, message, _ = Render(types.MessageTemplates{}, data)
+	assert.Contains(t, message, "1.0 nm away")
+}
+
+func TestRenderFrench(t *testing.T) {
+	i18n.Set(i18n.French)
+	defer i18n.Set(i18n.English)
+
+	data := Sample()
+	data.PlaneInfo = formatters.ApplyUnits(data.PlaneInfo, data.Units)
+	title, message, err := Render(types.MessageTemplates{}, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Avion repéré !", title)
+	assert.Equal(t, "📍 Home \nBAW12 \n ↑ 3\u00a0500 ft → 180 kts 🧭 268° \nTotal vu : 42 \n👀 regardez NE, 35° de haut, à 1,9 km", message)
+
+	_, message, _ = Render(types.MessageTemplates{Message: "{{clock .Time}} {{date .Time}}"}, data)
+	assert.Equal(t, "14 h 30 19/10/2026", message)
+
+	// The category is saved by name and shown in the current language
+	_, message, _ = Render(types.MessageTemplates{Message: "{{.Category}}"}, data)
+	assert.Equal(t, "Gros", message)
+
+	data.Area = states.Watchlist
+	_, message, _ = Render(types.MessageTemplates{Message: "{{.Area}}"}, data)
+	assert.Equal(t, "Liste de suivi", message)
+}
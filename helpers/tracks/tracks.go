@@ -0,0 +1,153 @@
+// Package tracks records a rolling track for each plane while it is in range,
+// and closes it into a Sighting once the plane leaves.
+
+package tracks
+
+import (
+	"math"
+	"planespotter/helpers/geo"
+	"planespotter/helpers/types"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxPoints is the number of points kept per track before the oldest are dropped
+const DefaultMaxPoints = 720
+
+// Recorder keeps the open track of every plane seen in the latest update
+type Recorder struct {
+	maxPoints int
+	open      map[string]*types.Sighting
+	lock      sync.Mutex
+}
+
+// NewRecorder takes the maximum number of points to keep per track and returns an empty Recorder
+// A maxPoints of 0 or less uses DefaultMaxPoints
+func NewRecorder(maxPoints int) *Recorder {
+	if maxPoints <= 0 {
+		maxPoints = DefaultMaxPoints
+	}
+
+	return &Recorder{maxPoints: maxPoints, open: make(map[string]*types.Sighting)}
+}
+
+// Update takes the planes from the latest check and the time of the check
+// Each plane's position is appended to its track, starting a new track for planes not already being tracked
+// Returns the Sightings for any planes that were being tracked but are no longer in range
+func (r *Recorder) Update(planeInfos []types.PlaneInfo, t time.Time) []types.Sighting {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	seen := make(map[string]bool)
+	for _, p := range planeInfos {
+		key := trackKey(p)
+		seen[key] = true
+
+		s, ok := r.open[key]
+		if !ok {
+			s = &types.Sighting{Icao24: p.Icao24, Callsign: p.Callsign, Profile: p.Area, FirstSeen: t}
+			r.open[key] = s
+		}
+		s.LastSeen = t
+		if s.Callsign == "N/A" {
+			s.Callsign = p.Callsign
+		}
+		if p.Category != "" {
+			s.Category = p.Category
+		}
+
+		if !p.State.Has_Position {
+			continue
+		}
+		if n := len(s.Track); n > 0 && s.Track[n-1].Latitude == p.State.Latitude && s.Track[n-1].Longitude == p.State.Longitude {
+			// The API repeats the last known position until a new one is received
+			continue
+		}
+
+		s.Track = append(s.Track, types.TrackPoint{
+			Time:      t,
+			Latitude:  p.State.Latitude,
+			Longitude: p.State.Longitude,
+			Altitude:  p.State.Baro_Altitude,
+			Velocity:  p.State.Velocity,
+			TrueTrack: p.State.True_Track,
+		})
+		if len(s.Track) > r.maxPoints {
+			s.Track = s.Track[len(s.Track)-r.maxPoints:]
+		}
+	}
+
+	var closed []types.Sighting
+	for key, s := range r.open {
+		if !seen[key] {
+			closed = append(closed, *s)
+			delete(r.open, key)
+		}
+	}
+	sortSightings(closed)
+
+	return closed
+}
+
+// Open returns a copy of the tracks currently being recorded, for drawing trails
+func (r *Recorder) Open() []types.Sighting {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var open []types.Sighting
+	for _, s := range r.open {
+		track := make([]types.TrackPoint, len(s.Track))
+		copy(track, s.Track)
+		open = append(open, *s)
+		open[len(open)-1].Track = track
+	}
+	sortSightings(open)
+
+	return open
+}
+
+// CloseAll closes every open track, for when spotting stops, and returns their Sightings
+func (r *Recorder) CloseAll() []types.Sighting {
+	return r.Update(nil, time.Time{})
+}
+
+// ClosestApproach takes a Sighting and the observer's Position, and returns the track point
+// closest to the observer with its distance in km
+// Returns false if the Sighting has no recorded track
+func ClosestApproach(s types.Sighting, observer types.Position) (types.TrackPoint, float64, bool) {
+	var closest types.TrackPoint
+	closestKm := math.Inf(1)
+	for _, point := range s.Track {
+		d := geo.DistanceKm(observer, types.Position{Latitude: point.Latitude, Longitude: point.Longitude})
+		if d < closestKm {
+			closest, closestKm = point, d
+		}
+	}
+
+	if len(s.Track) == 0 {
+		return closest, 0, false
+	}
+
+	return closest, closestKm, true
+}
+
+// trackKey takes a PlaneInfo and returns the key its track is recorded under
+// The icao24 address is unique per airframe, but fall back to the callsign if the API didn't send one
+func trackKey(p types.PlaneInfo) string {
+	if p.Icao24 != "N/A" {
+		return p.Icao24
+	}
+
+	return "callsign:" + p.Callsign
+}
+
+// sortSightings sorts Sightings by when they were first seen, then icao24, so results don't depend on map order
+func sortSightings(sightings []types.Sighting) {
+	sort.Slice(sightings, func(i, j int) bool {
+		if !sightings[i].FirstSeen.Equal(sightings[j].FirstSeen) {
+			return sightings[i].FirstSeen.Before(sightings[j].FirstSeen)
+		}
+		return sightings[i].Icao24 < sightings[j].Icao24
+	})
+}
@@ -0,0 +1,110 @@
+package tracks
+
+import (
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPlane(icao24, callsign string, lat, lon float64) types.PlaneInfo {
+	return types.PlaneInfo{
+		Icao24:   icao24,
+		Callsign: callsign,
+		State:    types.StateVector{Has_Position: true, Latitude: lat, Longitude: lon, Baro_Altitude: 1000, Velocity: 100, True_Track: 90},
+	}
+}
+
+func TestRecorderUpdate(t *testing.T) {
+	r := NewRecorder(0)
+	t0 := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	t2 := t1.Add(time.Minute)
+
+	closed := r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 51.0, 0.0), testPlane("def456", "EZY34", 52.0, 1.0)}, t0)
+	assert.Empty(t, closed)
+
+	// def456 repeats its last position, so no new point should be added
+	closed = r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 51.1, 0.1), testPlane("def456", "EZY34", 52.0, 1.0)}, t1)
+	assert.Empty(t, closed)
+
+	open := r.Open()
+	assert.Len(t, open, 2)
+	assert.Len(t, open[0].Track, 2)
+	assert.Len(t, open[1].Track, 1)
+
+	closed = r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 51.2, 0.2)}, t2)
+	assert.Len(t, closed, 1)
+	assert.Equal(t, "def456", closed[0].Icao24)
+	assert.Equal(t, "EZY34", closed[0].Callsign)
+	assert.Equal(t, t0, closed[0].FirstSeen)
+	assert.Equal(t, t1, closed[0].LastSeen)
+
+	closed = r.CloseAll()
+	assert.Len(t, closed, 1)
+	assert.Equal(t, "abc123", closed[0].Icao24)
+	assert.Equal(t, t2, closed[0].LastSeen)
+	assert.Equal(t, []types.TrackPoint{
+		{Time: t0, Latitude: 51.0, Longitude: 0.0, Altitude: 1000, Velocity: 100, TrueTrack: 90},
+		{Time: t1, Latitude: 51.1, Longitude: 0.1, Altitude: 1000, Velocity: 100, TrueTrack: 90},
+		{Time: t2, Latitude: 51.2, Longitude: 0.2, Altitude: 1000, Velocity: 100, TrueTrack: 90},
+	}, closed[0].Track)
+	assert.Empty(t, r.Open())
+}
+
+func TestRecorderMaxPoints(t *testing.T) {
+	r := NewRecorder(3)
+	t0 := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 51.0+float64(i)/10, 0.0)}, t0.Add(time.Duration(i)*time.Minute))
+	}
+
+	open := r.Open()
+	assert.Len(t, open[0].Track, 3)
+	assert.Equal(t, 51.2, open[0].Track[0].Latitude)
+	assert.Equal(t, t0, open[0].FirstSeen)
+}
+
+func TestRecorderCategory(t *testing.T) {
+	r := NewRecorder(0)
+	t0 := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	// The category is kept once known, as it is only in extended states
+	large := testPlane("abc123", "BAW12", 51.0, 0.0)
+	large.Category = "large"
+	r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 50.9, 0.0)}, t0)
+	r.Update([]types.PlaneInfo{large}, t0.Add(time.Minute))
+	r.Update([]types.PlaneInfo{testPlane("abc123", "BAW12", 51.1, 0.0)}, t0.Add(2*time.Minute))
+
+	closed := r.CloseAll()
+	assert.Equal(t, "large", closed[0].Category)
+}
+
+func TestRecorderWithoutPosition(t *testing.T) {
+	r := NewRecorder(0)
+	p := types.PlaneInfo{Icao24: "N/A", Callsign: "BAW12"}
+
+	r.Update([]types.PlaneInfo{p}, time.Now())
+	open := r.Open()
+	assert.Len(t, open, 1)
+	assert.Empty(t, open[0].Track)
+}
+
+func TestClosestApproach(t *testing.T) {
+	observer := types.Position{Latitude: 51.1, Longitude: 0.0}
+	s := types.Sighting{Track: []types.TrackPoint{
+		{Latitude: 51.0, Longitude: 0.0},
+		{Latitude: 51.1, Longitude: 0.1},
+		{Latitude: 51.2, Longitude: 0.2},
+	}}
+
+	point, km, ok := ClosestApproach(s, observer)
+	assert.True(t, ok)
+	assert.Equal(t, 0.1, point.Longitude)
+	assert.InDelta(t, 6.98, km, 0.01)
+
+	_, _, ok = ClosestApproach(types.Sighting{}, observer)
+	assert.False(t, ok)
+}
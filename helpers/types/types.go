@@ -2,6 +2,8 @@
 
 package types
 
+import "time"
+
 type SearchArea struct {
 	LaMin  string
 	LaMax  string
@@ -12,17 +14,70 @@ type SearchArea struct {
 	Area   string
 }
 
+// BoundingBox is a box of latitude and longitude in decimal degrees, as used for API searches
+type BoundingBox struct {
+	LaMin float64
+	LaMax float64
+	LoMin float64
+	LoMax float64
+}
+
+// Area is a named spot area around a position, from the active config or a watched profile
+type Area struct {
+	Name           string
+	Position       Position
+	SpotDistanceKm int
+	Shape          SpotShape
+	Volume         Volume
+}
+
+// SpotShape is the shape of a spot area. A blank Type is a radius of SpotDistanceKm around the position
+// A polygon is the area inside Points, and a corridor is within CorridorWidthKm either side of the line through Points
+type SpotShape struct {
+	Type            string
+	Points          []Position
+	CorridorWidthKm float64
+}
+
+// Volume limits a spot area by height and vertical rate, turning it into a 3D volume. Altitudes are in metres and
+// read from the barometric or geometric altitude depending on AltitudeSource. A zero MaxAltitudeM is no upper limit
+// VerticalRate is blank for any, or climbing, descending or level, split by MinVerticalRateMs in m/s
+type Volume struct {
+	MinAltitudeM      float64
+	MaxAltitudeM      float64
+	AltitudeSource    string
+	VerticalRate      string
+	MinVerticalRateMs float64
+}
+
+// ApiAuth is how to sign in to the OpenSky API. An API client's ClientId and ClientSecret get OAuth2 tokens from
+// TokenUrl, or OpenSky's if it is blank. Username and Password are used as basic auth if there is no ClientId
 type ApiAuth struct {
-	Username string
-	Password string
+	Username     string
+	Password     string
+	ClientId     string
+	ClientSecret string
+	TokenUrl     string
 }
 
+// Position is a point in decimal degrees. ElevationM is the height above sea level in metres, for the observer
 type Position struct {
-	Latitude  float64
-	Longitude float64
+	Latitude   float64
+	Longitude  float64
+	ElevationM float64
+}
+
+// LookAngles is where to look for a plane from the observer: AzimuthDeg clockwise from true north, ElevationDeg
+// above the horizon and SlantRangeKm in a straight line to the plane
+type LookAngles struct {
+	AzimuthDeg   float64
+	ElevationDeg float64
+	SlantRangeKm float64
 }
 
+// Result is a response from the OpenSky states API. Time is the Unix time the states are for
 type Result struct {
+	Time         int64           `json:"time"`
 	PlaneResults [][]interface{} `json:"states"`
 }
 
@@ -31,11 +86,186 @@ type Config struct {
 	ApiAuth          ApiAuth
 	SpotDistanceKm   int
 	CheckFreqSeconds int
+	Shape            SpotShape
+	Volume           Volume
+	DataSource       string
+	PassAlert        PassAlert
+	SunRule          string
+	Schedule         Schedule
+	Batching         Batching
+	Templates        MessageTemplates
+	Units            Units
+	Language         string
+	Notifiers        []NotifierConfig
+	Routes           map[string][]string
+	Basemap          Basemap
+	Geocoding        Geocoding
+	Secrets          Secrets
+	Budget           Budget
+	States           States
+	ActiveProfile    string
+	Profiles         []Profile
+}
+
+// Profile is a named observer location with its own settings, which are copied into Config when it is active
+// Progress is kept per profile as well as the combined total in SaveData
+type Profile struct {
+	Name             string
+	Position         Position
+	SpotDistanceKm   int
+	CheckFreqSeconds int
+	Shape            SpotShape
+	Volume           Volume
+	DataSource       string
+	Schedule         Schedule
+	Watch            bool
+	Progress         Progress
+}
+
+// PassAlert configures advance alerts for planes predicted to pass within WithinKm of the observer in the next
+// Minutes, before they reach the spot area. Zero Minutes turns them off
+type PassAlert struct {
+	WithinKm float64
+	Minutes  int
+}
+
+// Schedule is when notifications are wanted. Days are the days of the week to notify on, or every day if empty,
+// and QuietStart and QuietEnd are 24 hour times like "22:00" between which to stay quiet
+type Schedule struct {
+	Days       []string
+	QuietStart string
+	QuietEnd   string
+}
+
+// Batching is how new planes are grouped into notifications. Mode is each plane, per check or a digest every
+// DigestMinutes. MaxPerMinute caps how many notifications are sent in a minute, or 0 for no cap
+type Batching struct {
+	Mode          string
+	DigestMinutes int
+	MaxPerMinute  int
+}
+
+// MessageTemplates are text/templates for the title and message of new plane notifications, blank for the defaults
+type MessageTemplates struct {
+	Title   string
+	Message string
+}
+
+// Units are how altitudes, speeds, distances and vertical rates are shown, blank for feet, knots, km and ft/min
+// Settings are always stored in metres, km and m/s whatever the units
+type Units struct {
+	Altitude     string
+	Speed        string
+	Distance     string
+	VerticalRate string
+}
+
+// NotifierConfig is a backend alerts can be sent to. Type is desktop, log, webhook, email, mqtt or exec, and Address
+// is the webhook url, SMTP server or MQTT broker as host:port, or the command to run
+// Template, Headers, Secret and Retries are for webhooks: a text/template for the body, extra request headers,
+// a key to sign the body with, and how many times to retry, 0 for the default or -1 for none
+type NotifierConfig struct {
+	Name     string
+	Type     string
+	Address  string
+	Username string
+	Password string
+	From     string
+	To       []string
+	Topic    string
+	Args     []string
+	Template string
+	Headers  map[string]string
+	Secret   string
+	Retries  int
+}
+
+// Alert is something to tell the user about, such as a new plane spotted. Kind is used to route it to notifiers
+type Alert struct {
+	Kind    string
+	Title   string
+	Message string
+	Time    time.Time
+	Planes  []PlaneInfo
+}
+
+// Basemap configures the offline vector layers drawn under the aircraft on the map
+type Basemap struct {
+	Directory  string
+	Projection string
+}
+
+// Geocoding configures the place search. Gazetteer is a CSV file to search instead of the built in one,
+// and Url an online geocoder speaking the Nominatim search API, used when nothing is found offline
+type Geocoding struct {
+	Gazetteer string
+	Url       string
+}
+
+// Secrets configures where the OpenSky password and client secret are kept instead of the save. Backend is the
+// keyring, an encrypted File, or only environment variables, and blank keeps them in the save. Declined is set once
+// the user chooses to keep them in the save, so they aren't asked again
+type Secrets struct {
+	Backend  string
+	File     string
+	Declined bool
+}
+
+// Budget is how the day's OpenSky API credits are made to last. DailyCredits is how many there are, or 0 for OpenSky's
+// limit for the account. Adapt is whether to stretch the interval between checks, shrink the area first, or do neither
+type Budget struct {
+	DailyCredits int
+	Adapt        string
+}
+
+// States is which states to ask OpenSky for and keep. States last heard from more than MaxAgeSeconds before the
+// response are dropped, after 60 seconds if it is 0. Extended asks for aircraft categories, and a list of
+// Icao24 addresses is a watchlist followed anywhere in the world by a query of its own
+type States struct {
+	MaxAgeSeconds int
+	Extended      bool
+	Icao24        []string
+}
+
+// Place is a town, postcode area or airport found by a place search. Codes are its ICAO and IATA codes
+// for an airport, or the postcode area
+type Place struct {
+	Name     string
+	Kind     string
+	Country  string
+	Codes    []string
+	Position Position
 }
 
 type Progress struct {
 	SeenCount int
 	Callsigns []string
+	Sightings []Sighting
+}
+
+// Sighting is one continuous period a plane spent in range, along with its recorded track
+// Period is whether it was day or night at the closest approach, and Category the aircraft category if it was known
+// Profile is the area it was found in while it is being recorded, then the profile that area belongs to once saved
+type Sighting struct {
+	Icao24            string
+	Callsign          string
+	Category          string
+	FirstSeen         time.Time
+	LastSeen          time.Time
+	ClosestApproachKm float64
+	Profile           string
+	Period            string
+	Track             []TrackPoint
+}
+
+// TrackPoint is a single recorded position of a plane. Altitude is in metres, Velocity in m/s and TrueTrack in degrees
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	Velocity  float64
+	TrueTrack float64
 }
 
 type SaveData struct {
@@ -50,4 +280,26 @@ type PlaneInfo struct {
 	On_Ground     string
 	Velocity      string
 	True_Track    string
+	Vertical_Rate string
+	Area          string
+	Category      string
+	Look          LookAngles
+	State         StateVector
+}
+
+// StateVector holds the raw numeric values reported by the API for a plane, before formatting
+// The Has_ fields are false where the API returned null for the value
+type StateVector struct {
+	Has_Position      bool
+	Longitude         float64
+	Latitude          float64
+	Has_Baro_Altitude bool
+	Baro_Altitude     float64
+	Has_Geo_Altitude  bool
+	Geo_Altitude      float64
+	Has_Velocity      bool
+	Velocity          float64
+	True_Track        float64
+	Has_Vertical_Rate bool
+	Vertical_Rate     float64
 }
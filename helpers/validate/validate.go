@@ -0,0 +1,246 @@
+// Package validate checks settings are usable before they are saved or used, for the settings form,
+// the command line and when the save is loaded, so a typo can't quietly become 0,0 or a check every second.
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/schedule"
+	"planespotter/helpers/states"
+	"planespotter/helpers/templates"
+	"planespotter/helpers/types"
+	"strconv"
+	"strings"
+)
+
+// MaxSpotDistanceKm is the largest spot distance allowed. Larger areas cost the most OpenSky credits a query
+// and return more planes than anyone could look out for
+const MaxSpotDistanceKm = 500
+
+// MinCheckFreqSeconds is the shortest time between checks without an OpenSky account, as anonymous
+// state vectors only update every 10 seconds
+const MinCheckFreqSeconds = 10
+
+// MinAuthCheckFreqSeconds is the shortest time between checks with an OpenSky account, which get
+// state vectors every 5 seconds
+const MinAuthCheckFreqSeconds = 5
+
+// ParseNumber takes text and returns the decimal number in it, or an error if it isn't one
+// NaN and infinities are parsed by strconv but aren't numbers any setting can use, so they are errors too
+func ParseNumber(text string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || !finite(value) {
+		return 0, errors.New(i18n.T("validate.number"))
+	}
+
+	return value, nil
+}
+
+// ParseWhole takes text and returns the whole number in it, or an error if it isn't one
+func ParseWhole(text string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.New(i18n.T("validate.whole"))
+	}
+
+	return value, nil
+}
+
+// Number takes a check for a decimal number, and returns a validator for text that must be a number passing it,
+// as used by a Fyne Entry
+func Number(check func(float64) error) func(string) error {
+	return func(text string) error {
+		value, err := ParseNumber(text)
+		if err != nil {
+			return err
+		}
+		return check(value)
+	}
+}
+
+// Whole takes a check for a whole number, and returns a validator for text that must be a whole number passing it,
+// as used by a Fyne Entry
+func Whole(check func(int) error) func(string) error {
+	return func(text string) error {
+		value, err := ParseWhole(text)
+		if err != nil {
+			return err
+		}
+		return check(value)
+	}
+}
+
+// finite takes a number and returns whether it is neither NaN nor infinite, as NaN fails every comparison and
+// so would pass a range check
+func finite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// Latitude takes a latitude and returns an error unless it is between -90 and 90 degrees
+func Latitude(lat float64) error {
+	if !finite(lat) || lat < -90 || lat > 90 {
+		return errors.New(i18n.T("validate.latitude"))
+	}
+
+	return nil
+}
+
+// Longitude takes a longitude and returns an error unless it is between -180 and 180 degrees
+func Longitude(lon float64) error {
+	if !finite(lon) || lon < -180 || lon > 180 {
+		return errors.New(i18n.T("validate.longitude"))
+	}
+
+	return nil
+}
+
+// SpotDistanceKm takes a spot distance and returns an error unless it is more than 0 and at most MaxSpotDistanceKm
+func SpotDistanceKm(km float64) error {
+	if !finite(km) || km <= 0 || km > MaxSpotDistanceKm {
+		return errors.New(i18n.T("validate.spot_distance", MaxSpotDistanceKm))
+	}
+
+	return nil
+}
+
+// MinCheckFreq takes the ApiAuth and returns the shortest time allowed between checks in seconds,
+// which is shorter with an OpenSky account
+func MinCheckFreq(auth types.ApiAuth) int {
+	if auth.Username != "" || auth.ClientId != "" {
+		return MinAuthCheckFreqSeconds
+	}
+
+	return MinCheckFreqSeconds
+}
+
+// CheckFreqSeconds takes the ApiAuth and returns a check for the time between checks, which errors if it is
+// shorter than MinCheckFreq allows
+func CheckFreqSeconds(auth types.ApiAuth) func(int) error {
+	return func(seconds int) error {
+		if minimum := MinCheckFreq(auth); seconds < minimum {
+			return errors.New(i18n.T("validate.check_freq", minimum))
+		}
+
+		return nil
+	}
+}
+
+// NotNegative takes a number and returns an error if it is below 0
+func NotNegative(value float64) error {
+	if !finite(value) {
+		return errors.New(i18n.T("validate.number"))
+	}
+	if value < 0 {
+		return errors.New(i18n.T("validate.not_negative"))
+	}
+
+	return nil
+}
+
+// NotNegativeWhole takes a whole number and returns an error if it is below 0
+func NotNegativeWhole(value int) error {
+	return NotNegative(float64(value))
+}
+
+// MaxAltitude takes the minimum altitude and returns a check for the maximum altitude, which errors if it is negative
+// or below the minimum. 0 is allowed, as it means no maximum
+func MaxAltitude(minimum float64) func(float64) error {
+	return func(maximum float64) error {
+		if err := NotNegative(maximum); err != nil {
+			return err
+		}
+		if maximum > 0 && maximum < minimum {
+			return errors.New(i18n.T("validate.max_altitude"))
+		}
+
+		return nil
+	}
+}
+
+// Clock takes a 24 hour time such as "22:30", and returns an error if it isn't one. Blank is allowed
+func Clock(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if _, err := schedule.ParseClock(text); err != nil {
+		return errors.New(i18n.T("validate.clock"))
+	}
+
+	return nil
+}
+
+// Points takes shape points text with one "latitude, longitude" per line, and returns an error naming the line
+// if one can't be read, or the point if it is out of range
+func Points(text string) error {
+	points, err := areas.ParsePoints(text)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range points {
+		if err := errors.Join(Latitude(p.Latitude), Longitude(p.Longitude)); err != nil {
+			return fmt.Errorf("%v, %v: %w", p.Latitude, p.Longitude, err)
+		}
+	}
+
+	return nil
+}
+
+// Icao24 takes a watchlist of icao24 addresses separated by commas, spaces or new lines, and returns an error
+// naming the first one that isn't 6 hex digits. Blank is allowed, as it means no watchlist
+func Icao24(text string) error {
+	_, err := states.ParseIcao24(text)
+	return err
+}
+
+// Template takes a notification template and returns an error if it can't be parsed or run with a sample plane
+// Title and message templates are checked the same way. Blank is allowed, as it means the default
+func Template(text string) error {
+	_, _, err := templates.Render(types.MessageTemplates{Message: text}, templates.Sample())
+	return err
+}
+
+// Config takes a Config and returns every problem with it joined into one error, each naming the setting,
+// or nil if it is valid
+func Config(c types.Config) error {
+	var errs []error
+	add := func(setting string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", setting, err))
+		}
+	}
+
+	add("latitude", Latitude(c.Position.Latitude))
+	add("longitude", Longitude(c.Position.Longitude))
+	add("spot distance", SpotDistanceKm(float64(c.SpotDistanceKm)))
+	add("check frequency", CheckFreqSeconds(c.ApiAuth)(c.CheckFreqSeconds))
+	add("daily credits", NotNegativeWhole(c.Budget.DailyCredits))
+	add("drop states after", NotNegativeWhole(c.States.MaxAgeSeconds))
+	add("watchlist", Icao24(strings.Join(c.States.Icao24, " ")))
+	for _, p := range c.Shape.Points {
+		add(fmt.Sprintf("shape point %v, %v", p.Latitude, p.Longitude), errors.Join(Latitude(p.Latitude), Longitude(p.Longitude)))
+	}
+	add("corridor width", NotNegative(c.Shape.CorridorWidthKm))
+	add("minimum altitude", NotNegative(c.Volume.MinAltitudeM))
+	add("maximum altitude", MaxAltitude(c.Volume.MinAltitudeM)(c.Volume.MaxAltitudeM))
+	add("vertical rate threshold", NotNegative(c.Volume.MinVerticalRateMs))
+	add("pass alert distance", NotNegative(c.PassAlert.WithinKm))
+	add("pass alert time", NotNegativeWhole(c.PassAlert.Minutes))
+	add("quiet from", Clock(c.Schedule.QuietStart))
+	add("quiet until", Clock(c.Schedule.QuietEnd))
+	add("digest minutes", NotNegativeWhole(c.Batching.DigestMinutes))
+	add("max notifications a minute", NotNegativeWhole(c.Batching.MaxPerMinute))
+	add("notification title", Template(c.Templates.Title))
+	add("notification message", Template(c.Templates.Message))
+	for _, p := range c.Profiles {
+		add(fmt.Sprintf("profile %v latitude", p.Name), Latitude(p.Position.Latitude))
+		add(fmt.Sprintf("profile %v longitude", p.Name), Longitude(p.Position.Longitude))
+		add(fmt.Sprintf("profile %v spot distance", p.Name), SpotDistanceKm(float64(p.SpotDistanceKm)))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,151 @@
+package validate
+
+import (
+	"math"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		check    func(float64) error
+		text     string
+		expected string
+	}{
+		{check: Latitude, text: "51.47", expected: ""},
+		{check: Latitude, text: " -90 ", expected: ""},
+		{check: Latitude, text: "90.1", expected: "Must be between -90 and 90"},
+		{check: Latitude, text: "", expected: "Expected a number"},
+		{check: Latitude, text: "51,47", expected: "Expected a number"},
+		{check: Longitude, text: "-180", expected: ""},
+		{check: Longitude, text: "180.5", expected: "Must be between -180 and 180"},
+		{check: SpotDistanceKm, text: "20", expected: ""},
+		{check: SpotDistanceKm, text: "500", expected: ""},
+		{check: SpotDistanceKm, text: "0", expected: "Must be more than 0 and at most 500 km"},
+		{check: SpotDistanceKm, text: "501", expected: "Must be more than 0 and at most 500 km"},
+		{check: NotNegative, text: "0", expected: ""},
+		{check: NotNegative, text: "-1", expected: "Can't be negative"},
+		{check: Latitude, text: "NaN", expected: "Expected a number"},
+		{check: Longitude, text: "-Inf", expected: "Expected a number"},
+		{check: SpotDistanceKm, text: "+Inf", expected: "Expected a number"},
+		{check: NotNegative, text: " nan ", expected: "Expected a number"},
+	}
+
+	for _, test := range tests {
+		err := Number(test.check)(test.text)
+		if test.expected == "" {
+			assert.NoError(t, err, test.text)
+		} else {
+			assert.EqualError(t, err, test.expected, test.text)
+		}
+	}
+}
+
+func TestNotFinite(t *testing.T) {
+	tests := []struct {
+		check    func(float64) error
+		value    float64
+		expected string
+	}{
+		{check: Latitude, value: math.NaN(), expected: "Must be between -90 and 90"},
+		{check: Latitude, value: math.Inf(1), expected: "Must be between -90 and 90"},
+		{check: Longitude, value: math.NaN(), expected: "Must be between -180 and 180"},
+		{check: Longitude, value: math.Inf(-1), expected: "Must be between -180 and 180"},
+		{check: SpotDistanceKm, value: math.NaN(), expected: "Must be more than 0 and at most 500 km"},
+		{check: SpotDistanceKm, value: math.Inf(1), expected: "Must be more than 0 and at most 500 km"},
+		{check: NotNegative, value: math.NaN(), expected: "Expected a number"},
+		{check: NotNegative, value: math.Inf(1), expected: "Expected a number"},
+	}
+
+	for _, test := range tests {
+		assert.EqualError(t, test.check(test.value), test.expected, test.value)
+	}
+}
+
+func TestWhole(t *testing.T) {
+	tests := []struct {
+		check    func(int) error
+		text     string
+		expected string
+	}{
+		{check: CheckFreqSeconds(types.ApiAuth{}), text: "60", expected: ""},
+		{check: CheckFreqSeconds(types.ApiAuth{}), text: "10", expected: ""},
+		{check: CheckFreqSeconds(types.ApiAuth{}), text: "5", expected: "Must be at least 10 seconds"},
+		{check: CheckFreqSeconds(types.ApiAuth{Username: "spotter"}), text: "5", expected: ""},
+		{check: CheckFreqSeconds(types.ApiAuth{Username: "spotter"}), text: "4", expected: "Must be at least 5 seconds"},
+		{check: CheckFreqSeconds(types.ApiAuth{ClientId: "spotter-api-client"}), text: "5", expected: ""},
+		{check: CheckFreqSeconds(types.ApiAuth{}), text: "7.5", expected: "Expected a whole number"},
+		{check: NotNegativeWhole, text: "0", expected: ""},
+		{check: NotNegativeWhole, text: "-3", expected: "Can't be negative"},
+	}
+
+	for _, test := range tests {
+		err := Whole(test.check)(test.text)
+		if test.expected == "" {
+			assert.NoError(t, err, test.text)
+		} else {
+			assert.EqualError(t, err, test.expected, test.text)
+		}
+	}
+}
+
+func TestClock(t *testing.T) {
+	assert.NoError(t, Clock(""))
+	assert.NoError(t, Clock("22:30"))
+	assert.EqualError(t, Clock("25:00"), "Expected a 24 hour time like 22:30")
+	assert.EqualError(t, Clock("half ten"), "Expected a 24 hour time like 22:30")
+}
+
+func TestPoints(t *testing.T) {
+	assert.NoError(t, Points(""))
+	assert.NoError(t, Points("51.5, -0.1\n51.6, -0.2\n"))
+	assert.EqualError(t, Points("51.5, -0.1\nnorth"), "line 2: expected latitude, longitude")
+	assert.EqualError(t, Points("51.5, -0.1\n95, -0.2"), "95, -0.2: Must be between -90 and 90")
+}
+
+func TestIcao24(t *testing.T) {
+	assert.NoError(t, Icao24(""))
+	assert.NoError(t, Icao24("4007f5, 400A0B"))
+	assert.Error(t, Icao24("4007f5 BAW12"))
+}
+
+func TestTemplate(t *testing.T) {
+	assert.NoError(t, Template(""))
+	assert.NoError(t, Template("{{.Callsign}} at {{.Baro_Altitude}}"))
+	assert.Error(t, Template("{{.Callsign"))
+	assert.Error(t, Template("{{.NoSuchField}}"))
+}
+
+func TestConfig(t *testing.T) {
+	valid := types.Config{
+		Position:         types.Position{Latitude: 40.730610, Longitude: -73.935242},
+		CheckFreqSeconds: 60,
+		SpotDistanceKm:   20,
+	}
+	assert.NoError(t, Config(valid))
+
+	invalid := valid
+	invalid.Position.Latitude = 120
+	invalid.CheckFreqSeconds = 1
+	invalid.Volume = types.Volume{MinAltitudeM: 3000, MaxAltitudeM: 1000}
+	invalid.Schedule.QuietStart = "late"
+	invalid.States.Icao24 = []string{"BAW12"}
+	invalid.Profiles = []types.Profile{{Name: "Work", Position: types.Position{Latitude: 51.5, Longitude: -0.1}}}
+	assert.EqualError(t, Config(invalid), "latitude: Must be between -90 and 90\n"+
+		"check frequency: Must be at least 10 seconds\n"+
+		"watchlist: \"BAW12\": expected an icao24 address of 6 hex digits\n"+
+		"maximum altitude: Must be above the minimum altitude\n"+
+		"quiet from: Expected a 24 hour time like 22:30\n"+
+		"profile Work spot distance: Must be more than 0 and at most 500 km")
+}
+
+func TestFrench(t *testing.T) {
+	i18n.Set(i18n.French)
+	defer i18n.Set(i18n.English)
+
+	assert.EqualError(t, Number(Latitude)("abc"), "Un nombre est attendu")
+	assert.EqualError(t, Whole(CheckFreqSeconds(types.ApiAuth{}))("3"), "Doit être d'au moins 10 secondes")
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/export"
+	"planespotter/helpers/geocode"
+	"planespotter/helpers/types"
+	"planespotter/helpers/validate"
+	"strings"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+const usageText = `usage: planespotter [command] [flags]
+
+Run without a command to start the app.
+
+Commands:
+  export    write recorded sightings to a KML, GPX, CSV or JSON Lines file
+  import    merge sightings from CSV or JSON Lines exports into the save
+  geocode   find the coordinates of a town, UK postcode or airport code
+  check     check the settings in the save are valid`
+
+// runCommand takes the command line arguments (without the program name) and a Writer for output,
+// and runs the matching command. Returns an error if the command is unknown or fails
+func runCommand(args []string, stdout io.Writer) error {
+	switch args[0] {
+	case "export":
+		return exportCommand(args[1:], stdout)
+	case "import":
+		return importCommand(args[1:], stdout)
+	case "geocode":
+		return geocodeCommand(args[1:], stdout)
+	case "check":
+		return checkCommand(args[1:], stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(stdout, usageText)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%v", args[0], usageText)
+	}
+}
+
+// exportCommand takes the export command's flags, and writes the matching sightings from the save to a file,
+// or stdout if no file is given
+func exportCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	format := flags.String("format", "kml", "output format: kml, gpx, csv or jsonl")
+	data := flags.String("data", export.Sightings, "for csv and jsonl, what to export: "+strings.Join(export.Datasets, ", "))
+	out := flags.String("out", "", "file to write to (default stdout)")
+	from := flags.String("from", "", "only sightings on or after this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "only sightings before the end of this date (YYYY-MM-DD)")
+	callsigns := flags.String("callsign", "", "only these callsigns or icao24 addresses, comma separated")
+	save := flags.String("save", savePath, "save file to read sightings from")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fromTime, toTime, err := parseDateRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	saveData, err := GetSave(*save)
+	if err != nil {
+		return fmt.Errorf("error reading save: %w", err)
+	}
+
+	var callsignList []string
+	if *callsigns != "" {
+		callsignList = strings.Split(*callsigns, ",")
+	}
+	sightings := export.Filter(saveData.Sightings, fromTime, toTime, callsignList)
+
+	var write func(io.Writer, []types.Sighting) error
+	switch strings.ToLower(*format) {
+	case "kml":
+		write = func(w io.Writer, sightings []types.Sighting) error {
+			return export.WriteKml(w, sightings, saveData.Units)
+		}
+	case "gpx":
+		write = export.WriteGpx
+	case "csv":
+		write = func(w io.Writer, sightings []types.Sighting) error {
+			return export.WriteCsv(w, *data, saveData.SeenCount, sightings)
+		}
+	case "jsonl":
+		write = func(w io.Writer, sightings []types.Sighting) error {
+			return export.WriteJsonLines(w, *data, saveData.SeenCount, sightings)
+		}
+	default:
+		return fmt.Errorf("unknown export format %q, expected kml, gpx, csv or jsonl", *format)
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return write(w, sightings)
+}
+
+// importCommand takes the import command's flags and a list of sightings exports (.csv or .jsonl),
+// and merges the sightings from each into the save
+func importCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	save := flags.String("save", savePath, "save file to merge sightings into")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("no files to import, expected one or more sightings exports")
+	}
+
+	if err := CreateSaveIfNotExists(*save); err != nil {
+		return err
+	}
+	saveData, err := GetSave(*save)
+	if err != nil {
+		return fmt.Errorf("error reading save: %w", err)
+	}
+
+	for _, path := range flags.Args() {
+		sightings, err := readSightingsFile(path)
+		if err != nil {
+			return fmt.Errorf("error importing %v: %w", path, err)
+		}
+
+		before := len(saveData.Sightings)
+		saveData.Progress = export.Merge(saveData.Progress, sightings)
+		fmt.Fprintf(stdout, "%v: %v sightings, %v new\n", path, len(sightings), len(saveData.Sightings)-before)
+	}
+
+	SaveToFile(*save, saveData)
+	fmt.Fprintf(stdout, "Total seen: %v\n", saveData.SeenCount)
+	return nil
+}
+
+// geocodeCommand takes the geocode command's flags and a place to search for, and lists the places found with
+// their coordinates. With -set the first place found becomes the position in the save
+func geocodeCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("geocode", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	limit := flags.Int("limit", geocode.DefaultLimit, "how many places to list")
+	gazetteer := flags.String("gazetteer", "", "gazetteer CSV or GeoNames .txt to search instead of the one in the save")
+	online := flags.String("url", "", "Nominatim search url to try when nothing is found offline, instead of the one in the save")
+	set := flags.Bool("set", false, "set the position in the save to the first place found")
+	save := flags.String("save", savePath, "save file to read the geocoding settings from and set the position in")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return errors.New("nothing to search for, expected a town, postcode or airport code")
+	}
+
+	settings := types.Geocoding{Gazetteer: *gazetteer, Url: *online}
+	if _, err := os.Stat(*save); err == nil {
+		saveData, err := GetSave(*save)
+		if err != nil {
+			return fmt.Errorf("error reading save: %w", err)
+		}
+		if settings.Gazetteer == "" {
+			settings.Gazetteer = saveData.Geocoding.Gazetteer
+		}
+		if settings.Url == "" {
+			settings.Url = saveData.Geocoding.Url
+		}
+	}
+
+	geocoder, err := geocode.New(settings)
+	if err != nil {
+		return err
+	}
+	places, err := geocoder.Search(query, *limit)
+	if err != nil {
+		return fmt.Errorf("error searching for %q: %w", query, err)
+	}
+	if len(places) == 0 {
+		return fmt.Errorf("no places found for %q", query)
+	}
+
+	for _, p := range places {
+		fmt.Fprintf(stdout, "%v\t%v, %v\n", geocode.Describe(p), p.Position.Latitude, p.Position.Longitude)
+	}
+
+	if *set {
+		if err := CreateSaveIfNotExists(*save); err != nil {
+			return err
+		}
+		saveData, err := GetSave(*save)
+		if err != nil {
+			return fmt.Errorf("error reading save: %w", err)
+		}
+		saveData.Position.Latitude = places[0].Position.Latitude
+		saveData.Position.Longitude = places[0].Position.Longitude
+		SaveConfig(*save, saveData.Config)
+		fmt.Fprintf(stdout, "Position set to %v\n", geocode.Describe(places[0]))
+	}
+
+	return nil
+}
+
+// checkCommand takes the check command's flags, and checks the settings in the save, listing every problem found
+// Returns an error if there are any
+func checkCommand(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	save := flags.String("save", savePath, "save file to check")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	saveData, err := GetSave(*save)
+	if err != nil {
+		return fmt.Errorf("error reading save: %w", err)
+	}
+	if err := validate.Config(saveData.Config); err != nil {
+		return fmt.Errorf("problems with the settings in %v:\n%w", *save, err)
+	}
+
+	fmt.Fprintf(stdout, "The settings in %v are valid\n", *save)
+	return nil
+}
+
+// readSightingsFile takes the path to a sightings export, and reads it as CSV or JSON Lines depending on its extension
+func readSightingsFile(path string) ([]types.Sighting, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return export.ReadSightingsCsv(f)
+	case ".jsonl", ".json":
+		return export.ReadSightingsJsonLines(f)
+	default:
+		return nil, fmt.Errorf("unknown file type %q, expected .csv or .jsonl", filepath.Ext(path))
+	}
+}
+
+// parseDateRange takes from and to dates as YYYY-MM-DD strings, either of which can be empty
+// Returns the start of the from date and the end of the to date in local time
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	var fromTime, toTime time.Time
+	var err error
+	if from != "" {
+		fromTime, err = time.ParseInLocation(dateFormat, from, time.Local)
+		if err != nil {
+			return fromTime, toTime, fmt.Errorf("invalid from date %q, expected YYYY-MM-DD", from)
+		}
+	}
+	if to != "" {
+		toTime, err = time.ParseInLocation(dateFormat, to, time.Local)
+		if err != nil {
+			return fromTime, toTime, fmt.Errorf("invalid to date %q, expected YYYY-MM-DD", to)
+		}
+		toTime = toTime.AddDate(0, 0, 1)
+	}
+	if !fromTime.IsZero() && !toTime.IsZero() && !fromTime.Before(toTime) {
+		return fromTime, toTime, errors.New("from date must not be after to date")
+	}
+
+	return fromTime, toTime, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"planespotter/helpers/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, runCommand([]string{"help"}, &out))
+	assert.Contains(t, out.String(), "export")
+
+	assert.Error(t, runCommand([]string{"fly"}, &out))
+}
+
+func TestExportCommand(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	seen := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	SaveSightings(testSavePath, []types.Sighting{
+		{
+			Icao24:    "testicao",
+			Callsign:  "testcallsign",
+			FirstSeen: seen,
+			LastSeen:  seen.Add(time.Minute),
+			Track:     []types.TrackPoint{{Time: seen, Latitude: 40.8, Longitude: -73.9, Altitude: 1000}},
+		},
+	})
+
+	var out bytes.Buffer
+	err = runCommand([]string{"export", "-save", testSavePath, "-format", "gpx", "-from", "2026-10-19", "-to", "2026-10-19"}, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "<name>testcallsign</name>")
+
+	out.Reset()
+	err = runCommand([]string{"export", "-save", testSavePath, "-format", "kml", "-from", "2026-10-20"}, &out)
+	assert.NoError(t, err)
+	assert.NotContains(t, out.String(), "testcallsign")
+
+	err = runCommand([]string{"export", "-save", testSavePath, "-format", "shp"}, &out)
+	assert.Error(t, err)
+
+	err = runCommand([]string{"export", "-save", testSavePath, "-from", "19/10/2026"}, &out)
+	assert.Error(t, err)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportCommand(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	importPath := filepath.Join(t.TempDir(), "sightings.csv")
+	err = os.WriteFile(importPath, []byte("icao24,callsign,first_seen,last_seen,closest_approach_km,track_points\ntesticao,testcallsign,2026-10-19T12:00:00Z,2026-10-19T12:05:00Z,2.50,0\n"), 0644)
+	if err != nil {
+		t.Error(err)
+	}
+
+	var out bytes.Buffer
+	err = runCommand([]string{"import", "-save", testSavePath, importPath, importPath}, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "1 sightings, 1 new")
+	assert.Contains(t, out.String(), "1 sightings, 0 new")
+
+	s, err := GetSave(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, 1, s.SeenCount)
+	assert.Equal(t, []string{"testcallsign"}, s.Callsigns)
+	assert.Len(t, s.Sightings, 1)
+
+	out.Reset()
+	err = runCommand([]string{"export", "-save", testSavePath, "-format", "csv", "-data", "stats"}, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "1,1,1,1,2026-10-19T12:00:00Z,2026-10-19T12:05:00Z")
+
+	assert.Error(t, runCommand([]string{"import", "-save", testSavePath}, &out))
+	assert.Error(t, runCommand([]string{"import", "-save", testSavePath, "sightings.kml"}, &out))
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, runCommand([]string{"check", "-save", testSavePath}, &out))
+	assert.Equal(t, "The settings in "+testSavePath+" are valid\n", out.String())
+
+	SaveConfig(testSavePath, types.Config{Position: types.Position{Latitude: 95, Longitude: 2}, SpotDistanceKm: 20, CheckFreqSeconds: 2})
+	err = runCommand([]string{"check", "-save", testSavePath}, &out)
+	assert.EqualError(t, err, "problems with the settings in "+testSavePath+":\n"+
+		"latitude: Must be between -90 and 90\n"+
+		"check frequency: Must be at least 10 seconds")
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGeocodeCommand(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, runCommand([]string{"geocode", "-limit", "1", "lhr"}, &out))
+	assert.Equal(t, "London Heathrow Airport (EGLL LHR), GB\t51.47, -0.4543\n", out.String())
+
+	assert.Error(t, runCommand([]string{"geocode"}, &out))
+	assert.EqualError(t, runCommand([]string{"geocode", "Xyzzyville"}, &out), `no places found for "Xyzzyville"`)
+
+	out.Reset()
+	assert.NoError(t, runCommand([]string{"geocode", "-save", testSavePath, "-set", "Gatwick"}, &out))
+	assert.Contains(t, out.String(), "Position set to London Gatwick Airport")
+
+	s, err := GetSave(testSavePath)
+	assert.NoError(t, err)
+	assert.Equal(t, types.Position{Latitude: 51.1537, Longitude: -0.1821}, s.Position)
+
+	// The gazetteer in the save is searched unless another is given
+	gazetteer := filepath.Join(t.TempDir(), "gazetteer.csv")
+	assert.NoError(t, os.WriteFile(gazetteer, []byte("name,kind,country,codes,latitude,longitude\nHome Field,airport,GB,EGXX,51.5,-0.1\n"), 0644))
+	s.Geocoding.Gazetteer = gazetteer
+	SaveConfig(testSavePath, s.Config)
+
+	out.Reset()
+	assert.NoError(t, runCommand([]string{"geocode", "-save", testSavePath, "EGXX"}, &out))
+	assert.Equal(t, "Home Field (EGXX), GB\t51.5, -0.1\n", out.String())
+	assert.Error(t, runCommand([]string{"geocode", "-save", testSavePath, "-gazetteer", "missing.csv", "EGXX"}, &out))
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -7,38 +7,77 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"planespotter/assets"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/auth"
+	"planespotter/helpers/batch"
+	"planespotter/helpers/budget"
+	"planespotter/helpers/fetch"
 	"planespotter/helpers/formatters"
+	"planespotter/helpers/geo"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/notifiers"
+	"planespotter/helpers/predict"
+	"planespotter/helpers/profiles"
+	"planespotter/helpers/schedule"
+	"planespotter/helpers/states"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/templates"
+	"planespotter/helpers/tracks"
 	"planespotter/helpers/types"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2/data/binding"
-	"github.com/gen2brain/beeep"
 	"golang.org/x/exp/slices"
 )
 
-const baseUrl = "opensky-network.org/api/states/all?"
+const baseUrl = "https://opensky-network.org/api/states/all"
 
-const StartedText = "Spotting 🔭"
-const StoppedText = "Stopped 🛑"
-const ErrorText = "Error ⚠️"
+// StartedText, StoppedText and ErrorText are the catalogue keys of the status shown under the buttons
+const StartedText = "status.started"
+const StoppedText = "status.stopped"
+const ErrorText = "status.error"
 
 var savePath = "save.json"
 var testSavePath = "test_save.json"
 var pauseLoop = make(chan bool)
 var started bool = false
 var status = binding.NewString()
+var recorder = tracks.NewRecorder(tracks.DefaultMaxPoints)
+var passWatcher = predict.NewWatcher()
+var missed schedule.Missed
+var batcher batch.Batcher
+var router *notifiers.Router
+var apiAuth auth.Authorizer = auth.None{}
+var secretsPassphrase string
+var credits = budget.NewTracker(budget.AnonymousCredits)
+var budgetStatus = binding.NewString()
+var apiClient = fetch.NewClient(fetch.DefaultTimeout)
+var retryBackoff = fetch.Backoff{Base: 5 * time.Second, Max: 5 * time.Minute}
+var feed = states.NewFeed(states.DefaultMaxAgeSeconds * time.Second)
 
-// main creates a new save if required
+// main runs a command if one is given on the command line
+// Otherwise creates a new save if required
 // Loads save data
 // Starts the UI
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	err := CreateSaveIfNotExists(savePath)
 	if err != nil {
 		log.Println("Error creating save file")
 	}
 
 	url, saveData := InitSaveData(savePath)
-	_, window := InitUi(url, savePath, saveData)
+	app, window := InitUi(url, savePath, saveData)
+	SecretsPrompt(app, window, savePath, saveData)
 
 	window.CenterOnScreen()
 	window.ShowAndRun()
@@ -47,8 +86,15 @@ func main() {
 // startUpdateLoop takes the API url and saveData, and begins the updateLoop to check for new planes and notify the user
 func startUpdateLoop(url string, saveData types.SaveData) {
 	log.Println("Spotting started")
+	if router != nil {
+		router.Close()
+	}
+	router = newRouter(saveData)
+	apiAuth = auth.New(saveData.ApiAuth)
+	credits.SetDaily(budget.DailyCredits(saveData.Budget.DailyCredits, saveData.ApiAuth))
+	feed = states.NewFeed(states.MaxAge(saveData.States))
 	started = true
-	status.Set(StartedText)
+	status.Set(i18n.T(StartedText))
 	go updateLoop(url, saveData)
 }
 
@@ -56,71 +102,386 @@ func startUpdateLoop(url string, saveData types.SaveData) {
 func stopUpdateLoop() {
 	log.Println("Spotting stopped")
 	started = false
-	status.Set(StoppedText)
+	status.Set(i18n.T(StoppedText))
 	pauseLoop <- true
+	SaveSightings(savePath, recorder.CloseAll())
 }
 
-// updateLoop takes the API url and saveData, and triggers a check for new planes
+// watchQuery is one API request made each check, the spot areas it covers and the API credits it costs
+// A watchlist query covers no areas, as it asks for the watchlisted aircraft wherever they are
+type watchQuery struct {
+	url       string
+	areas     []types.Area
+	credits   int
+	watchlist bool
+}
+
+// updateLoop takes the API url and saveData, and triggers a check for new planes in every watched area
+// It records each plane's track, saving it as a sighting once the plane has left the area
 // It sends the results to notifyIfNew to send notifications
-// It checks based on the check frequency specified in the config
+// It checks based on the check frequency specified in the config, stretched by planChecks if the API credits
+// wouldn't last the day, and waits as long as the API asks when it refuses a request
+// A failed check doesn't stop it, the check is tried again after retryDelay, which may be sooner than usual
+// A check that gets no newer states than the last one is skipped
 // It stops when it receives on the pauseLoop channel
 func updateLoop(url string, saveData types.SaveData) {
-	timeSinceCheck := saveData.CheckFreqSeconds
+	minimum := time.Duration(saveData.CheckFreqSeconds) * time.Second
+	queries, interval := planChecks(url, saveData, minimum)
+	log.Printf("Watching %v areas with %v queries", len(profiles.Areas(saveData.Config)), len(queries))
+	passUrl := passQuery(saveData)
+	sinceCheck := interval
+	failures := 0
 	for range time.Tick(time.Second) {
-		timeSinceCheck += 1
+		sinceCheck += time.Second
 		select {
 		case <-pauseLoop:
 			return
 		default:
-			if timeSinceCheck >= saveData.CheckFreqSeconds {
-				planeInfos, err := updatePlanes(url)
-				if err != nil {
-					log.Printf("Error updating planes: %v", err)
-					status.Set(ErrorText + " - " + err.Error())
-					started = false
-					pauseLoop <- true
+			if sinceCheck >= interval {
+				planeInfos, err := updateAreas(queries)
+				queries, interval = planChecks(url, saveData, minimum)
+				sinceCheck = 0
+				unchanged := errors.Is(err, states.ErrUnchanged)
+				if err != nil && !unchanged {
+					// Open tracks are left alone, so a plane isn't saved as a sighting just because a check failed
+					failures++
+					interval = retryDelay(err, failures, interval, credits.Wait(time.Now()))
+					log.Printf("Error updating planes, retrying in %v: %v", interval, err)
+					status.Set(i18n.T(ErrorText) + " - " + i18n.T("status.retrying", errorText(err), interval.Round(time.Second)))
+					continue
+				}
+				if failures > 0 {
+					failures = 0
+					status.Set(i18n.T(StartedText))
 				}
+				if unchanged {
+					// The same states again would only add duplicate track points
+					log.Println("No new states since the last check")
+					continue
+				}
+
+				planeInfos = withUnits(lookFrom(saveData.Position, planeInfos), saveData.Units)
 				log.Printf("Received %v planes", len(planeInfos))
+				SaveSightings(savePath, recorder.Update(planeInfos, time.Now()))
+				updateMap(planeInfos, recorder.Open())
 				notifyIfNew(planeInfos)
-				timeSinceCheck = 0
+				if passUrl != "" && sun.Allowed(saveData.SunRule, saveData.Position, time.Now()) && !schedule.IsQuiet(saveData.Schedule, time.Now()) {
+					checkPasses(passUrl, saveData)
+				}
+			}
+		}
+	}
+}
+
+// retryDelay takes the error from a failed check, how many checks in a row have failed, the usual time between
+// checks and how long the API has asked to be left alone for, and returns how long to wait before checking again
+// Network and server errors are retried after the backoff delay, sooner than the usual check if that is shorter,
+// but never before the API allows. Errors that won't clear up on their own, such as rejected credentials,
+// wait the longest so the API isn't asked again and again for nothing
+func retryDelay(err error, failures int, interval, retryAfter time.Duration) time.Duration {
+	if !fetch.Retryable(err) {
+		return max(interval, retryBackoff.Max, retryAfter)
+	}
+
+	return max(retryBackoff.Delay(failures), retryAfter)
+}
+
+// errorText takes the error from a failed check and returns a short description of what went wrong for the status
+func errorText(err error) string {
+	kind := fetch.KindOf(err)
+	if kind == "" {
+		return err.Error()
+	}
+
+	return i18n.T("error." + strings.ReplaceAll(kind, " ", "_"))
+}
+
+// watchQueries takes the API url for the active area and saveData, and returns the queries to make each check
+// If only the active area is watched that is just the url, otherwise watched areas are merged into as few
+// queries as possible to save API credits
+func watchQueries(url string, saveData types.SaveData) []watchQuery {
+	return queriesFor(url, saveData, profiles.Areas(saveData.Config))
+}
+
+// queriesFor takes the API url for the active area, saveData and the watched areas, and returns the queries to make
+// for them. A blank url is built from the area's box
+func queriesFor(url string, saveData types.SaveData, watched []types.Area) []watchQuery {
+	if len(watched) == 1 {
+		box := areas.Box(watched[0])
+		if url == "" {
+			url = SearchUrl(saveData, BoxSearchArea(box))
+		}
+		return []watchQuery{{url: url, areas: watched, credits: areas.Credits(box)}}
+	}
+
+	var queries []watchQuery
+	for _, group := range areas.Merge(watched) {
+		queries = append(queries, watchQuery{url: SearchUrl(saveData, BoxSearchArea(group.Box)), areas: group.Areas, credits: areas.Credits(group.Box)})
+	}
+
+	return queries
+}
+
+// planChecks takes the API url for the active area, saveData and the shortest time allowed between checks, and
+// returns the queries to make next and how long to wait before making them, so the day's API credits last until
+// they are reset. Depending on the Budget, the interval is stretched, the watched areas are shrunk to cheaper
+// queries first, or neither. It shows the credits left and when they will run out under the status
+func planChecks(url string, saveData types.SaveData, minimum time.Duration) ([]watchQuery, time.Duration) {
+	now := time.Now()
+	remaining := credits.Remaining(now)
+	queries := watchQueries(url, saveData)
+	watchlist := watchlistQueries(saveData)
+	cost := checkCredits(queries) + checkCredits(watchlist) + passCredits(saveData)
+	interval := budget.CheckInterval(remaining, cost, minimum, now)
+
+	if saveData.Budget.Adapt == budget.Area {
+		watched := profiles.Areas(saveData.Config)
+		for maxCredits := maxQueryCredits(queries) - 1; maxCredits >= 1 && interval > minimum; maxCredits-- {
+			shrunk := make([]types.Area, len(watched))
+			for i, a := range watched {
+				shrunk[i] = budget.Shrink(a, maxCredits)
+			}
+			queries = queriesFor("", saveData, shrunk)
+			cost = checkCredits(queries) + checkCredits(watchlist) + passCredits(saveData)
+			interval = budget.CheckInterval(remaining, cost, minimum, now)
+		}
+	}
+	if saveData.Budget.Adapt == budget.Off {
+		interval = minimum
+	}
+
+	wait := credits.Wait(now)
+	interval = max(interval, wait)
+	budgetStatus.Set(budgetText(remaining, cost, interval, minimum, wait, now))
+
+	return append(queries, watchlist...), interval
+}
+
+// watchlistQueries takes saveData and returns the query for the watchlisted aircraft, which has no box so it costs
+// as much as a query for the whole world, or none if there is no watchlist
+func watchlistQueries(saveData types.SaveData) []watchQuery {
+	url := WatchlistUrl(saveData)
+	if url == "" {
+		return nil
+	}
+
+	return []watchQuery{{url: url, credits: areas.GlobalCredits, watchlist: true}}
+}
+
+// budgetText takes the credits left, the credits each check costs, the interval between checks, the shortest
+// interval allowed, how long the API has asked to wait and the time, and returns the budget shown under the status
+func budgetText(remaining, cost int, interval, minimum, wait time.Duration, now time.Time) string {
+	text := i18n.T("status.credits_left", i18n.Number(float64(remaining), 0))
+	if at, ok := budget.Exhaustion(remaining, cost, interval, now); remaining < cost {
+		text += ", " + i18n.T("status.credits_none", i18n.Clock(budget.NextReset(now).In(now.Location())))
+	} else if ok {
+		text += ", " + i18n.T("status.credits_run_out", i18n.Clock(at))
+	} else {
+		text += ", " + i18n.T("status.credits_last")
+	}
+
+	switch {
+	case wait > 0:
+		text += "\n" + i18n.T("status.rate_limited", wait.Round(time.Second))
+	case interval > minimum:
+		text += "\n" + i18n.T("status.checking_every", interval)
+	}
+	return text
+}
+
+// checkCredits takes the queries made each check and returns the API credits they cost
+func checkCredits(queries []watchQuery) int {
+	total := 0
+	for _, q := range queries {
+		total += q.credits
+	}
+	return total
+}
+
+// maxQueryCredits takes the queries made each check and returns the most API credits any one of them costs
+func maxQueryCredits(queries []watchQuery) int {
+	most := 0
+	for _, q := range queries {
+		most = max(most, q.credits)
+	}
+	return most
+}
+
+// lookFrom takes the observer's Position and a slice of PlaneInfo, and returns them with Look set to where to look
+// for each plane that has a position and altitude
+func lookFrom(observer types.Position, planeInfos []types.PlaneInfo) []types.PlaneInfo {
+	for i, p := range planeInfos {
+		planeInfos[i].Look, _ = geo.Look(observer, p.State)
+	}
+
+	return planeInfos
+}
+
+// withUnits takes a slice of PlaneInfo and the configured Units, and returns them with their altitude, velocity
+// and vertical rate shown in those units
+func withUnits(planeInfos []types.PlaneInfo, units types.Units) []types.PlaneInfo {
+	for i, p := range planeInfos {
+		planeInfos[i] = formatters.ApplyUnits(p, units)
+	}
+
+	return planeInfos
+}
+
+// passQuery takes saveData and returns the API url to search for planes that could pass close by within the
+// pass alert time, or a blank url if pass alerts are off
+func passQuery(saveData types.SaveData) string {
+	if saveData.PassAlert.Minutes <= 0 {
+		return ""
+	}
+
+	return SearchUrl(saveData, BoxSearchArea(passBox(saveData)))
+}
+
+// passCredits takes saveData and returns the API credits the pass alert query costs, or 0 if pass alerts are off
+func passCredits(saveData types.SaveData) int {
+	if saveData.PassAlert.Minutes <= 0 {
+		return 0
+	}
+
+	return areas.Credits(passBox(saveData))
+}
+
+// passBox takes saveData and returns the box searched for planes that could pass close by
+func passBox(saveData types.SaveData) types.BoundingBox {
+	return areas.Box(types.Area{Position: saveData.Position, SpotDistanceKm: predict.SearchRadiusKm(saveData.PassAlert)})
+}
+
+// checkPasses takes the pass alert API url and saveData, and notifies the user of any planes predicted to pass
+// close by soon that haven't been alerted yet
+func checkPasses(url string, saveData types.SaveData) {
+	planeInfos, err := updatePlanes(url, passCredits(saveData))
+	if errors.Is(err, states.ErrUnchanged) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error checking for passes: %v", err)
+		return
+	}
+
+	for _, pass := range passWatcher.Due(planeInfos, saveData.Position, saveData.PassAlert, time.Now()) {
+		plane := formatters.ApplyUnits(pass.Plane, saveData.Units)
+		messageBody := i18n.T("notify.pass_message", plane.Callsign, pass.TimeToCpa.Round(time.Second), formatters.FormatDistance(pass.CpaDistanceKm, saveData.Units.Distance), i18n.Number(pass.ElevationDeg, 0), formatters.FormatCompass(geo.Bearing(saveData.Position, pass.Cpa)))
+		notify(types.Alert{Kind: notifiers.Approaching, Title: i18n.T("notify.approaching_title"), Message: messageBody, Time: time.Now(), Planes: []types.PlaneInfo{plane}})
+	}
+}
+
+// updateAreas takes the watch queries and makes each one, returning the planes found tagged with the area they are in
+// A plane returned by more than one query is only included once, so a watchlisted plane inside an area keeps its
+// area, as the watchlist query is made last
+// Returns an error if any query fails, or states.ErrUnchanged with the planes if no query had anything new
+func updateAreas(queries []watchQuery) ([]types.PlaneInfo, error) {
+	var planeInfos []types.PlaneInfo
+	seen := make(map[string]bool)
+	unchanged := 0
+	for _, q := range queries {
+		res, err := updatePlanes(q.url, q.credits)
+		if errors.Is(err, states.ErrUnchanged) {
+			unchanged++
+		} else if err != nil {
+			return planeInfos, err
+		}
+
+		tagged := areas.Tag(res, q.areas)
+		if q.watchlist {
+			tagged = watchlisted(res)
+		}
+		for _, p := range tagged {
+			if seen[p.Icao24+p.Callsign] {
+				continue
 			}
+			seen[p.Icao24+p.Callsign] = true
+			planeInfos = append(planeInfos, p)
 		}
 	}
+
+	if len(queries) > 0 && unchanged == len(queries) {
+		return planeInfos, states.ErrUnchanged
+	}
+
+	return planeInfos, nil
+}
+
+// watchlisted takes the planes returned by the watchlist query and returns them with their Area set to the watchlist
+func watchlisted(planeInfos []types.PlaneInfo) []types.PlaneInfo {
+	for i := range planeInfos {
+		planeInfos[i].Area = states.Watchlist
+	}
+
+	return planeInfos
 }
 
-// updatePlanes takes the API url and returns a slice of PlaneInfo
-// It also logs out the remaining API requests to help monitor API usage
-// Returns an error if the request to the API or parsing the response fails
-func updatePlanes(url string) ([]types.PlaneInfo, error) {
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != 200 {
-		errorString := fmt.Sprintf("error getting response from server: status %v | error %v", resp.StatusCode, err)
-		return []types.PlaneInfo{}, errors.New(errorString)
+// updatePlanes takes the API url and the credits the query costs, and returns a slice of PlaneInfo
+// Requests are signed in with apiAuth, and a rejected token is forgotten so the next request gets a new one
+// It also tracks the API credits left, from the API's count when the response has one or by spending the cost
+// when it doesn't, and when the API refuses a request for lack of them, how long to wait
+// States not heard from recently are dropped. A response no newer than the last one for the url returns its planes
+// with states.ErrUnchanged, so the caller can skip them but still knows what is in range
+// Returns a fetch.Error saying whether the request couldn't be made, was refused or couldn't be read, wrapping
+// budget.ErrRateLimited if the API refused it for lack of credits
+func updatePlanes(url string, cost int) ([]types.PlaneInfo, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return []types.PlaneInfo{}, &fetch.Error{Kind: fetch.Parse, Err: err}
+	}
+	if err := apiAuth.Authorize(req); err != nil {
+		if fetch.KindOf(err) == "" {
+			err = &fetch.Error{Kind: fetch.Auth, Err: err}
+		}
+		return []types.PlaneInfo{}, err
 	}
 
+	resp, err := apiClient.Do(req)
+	if err := fetch.Classify(resp, err); err != nil {
+		switch fetch.KindOf(err) {
+		case fetch.Auth:
+			apiAuth.Reset()
+		case fetch.RateLimit:
+			now := time.Now()
+			credits.Observe("0", now)
+			credits.RetryAfter(resp.Header.Get("X-Rate-Limit-Retry-After-Seconds"), now)
+			err = &fetch.Error{Kind: fetch.RateLimit, Status: resp.StatusCode, Err: fmt.Errorf("%w, waiting %v", budget.ErrRateLimited, credits.Wait(now).Round(time.Second))}
+		}
+		return []types.PlaneInfo{}, err
+	}
+	defer resp.Body.Close()
+
 	remainingRequests := resp.Header.Get("X-Rate-Limit-Remaining")
 	log.Printf("Remaining API requests today: %v\n", remainingRequests)
+	if !credits.Observe(remainingRequests, time.Now()) {
+		credits.Spend(cost, time.Now())
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		errorString := fmt.Sprintf("error reading response from server: status %v | error %v", resp.StatusCode, err)
-		return []types.PlaneInfo{}, errors.New(errorString)
+		return []types.PlaneInfo{}, &fetch.Error{Kind: fetch.Network, Status: resp.StatusCode, Err: err}
 	}
-	defer resp.Body.Close()
 
 	var r types.Result
 
 	if err := json.Unmarshal(body, &r); err != nil {
-		errorString := fmt.Sprintf("error parsing response from server: status %v | error %v", resp.StatusCode, err)
-		return []types.PlaneInfo{}, errors.New(errorString)
+		return []types.PlaneInfo{}, &fetch.Error{Kind: fetch.Parse, Status: resp.StatusCode, Err: err}
+	}
+
+	fresh, newer := feed.Update(url, r)
+	if dropped := len(r.PlaneResults) - len(fresh); dropped > 0 {
+		log.Printf("Dropped %v stale states", dropped)
 	}
 
 	var planeInfos []types.PlaneInfo
-	for _, plane := range r.PlaneResults {
+	for _, plane := range fresh {
 		parsedResult := parseResult(plane)
 		planeInfos = append(planeInfos, parsedResult)
 	}
 
+	if !newer {
+		return planeInfos, states.ErrUnchanged
+	}
+
 	return planeInfos, nil
 }
 
@@ -131,23 +492,70 @@ func parseResult(res []interface{}) types.PlaneInfo {
 
 	p.Icao24 = formatters.FormatIcao24(res[0])
 	p.Callsign = formatters.FormatCallsign(res[1])
-	p.Baro_Altitude = formatters.FormatBaroAltitude(res[7])
 	p.On_Ground = formatters.FormatOnGround(res[8])
-	p.Velocity = formatters.FormatVelocity(res[9])
 	p.True_Track = formatters.FormatTrueTrack(res[10])
 
-	return p
+	p.Category = states.Category(res)
+
+	longitude, hasLongitude := formatters.ParseFloat(res[5])
+	latitude, hasLatitude := formatters.ParseFloat(res[6])
+	p.State.Has_Position = hasLongitude && hasLatitude
+	p.State.Longitude = longitude
+	p.State.Latitude = latitude
+	p.State.Baro_Altitude, p.State.Has_Baro_Altitude = formatters.ParseFloat(res[7])
+	p.State.Geo_Altitude, p.State.Has_Geo_Altitude = formatters.ParseFloat(res[13])
+	p.State.Velocity, p.State.Has_Velocity = formatters.ParseFloat(res[9])
+	p.State.True_Track, _ = formatters.ParseFloat(res[10])
+	p.State.Vertical_Rate, p.State.Has_Vertical_Rate = formatters.ParseFloat(res[11])
+
+	// Shown in the default units until the loop applies the configured ones
+	return formatters.ApplyUnits(p, types.Units{})
+}
+
+// newRouter takes saveData and returns a Router for its notifiers and routes
+// If they aren't set up correctly the error is logged and alerts go to desktop notifications instead
+func newRouter(saveData types.SaveData) *notifiers.Router {
+	r, err := notifiers.NewRouter(saveData.Notifiers, saveData.Routes, assets.PlanePath())
+	if err != nil {
+		log.Printf("Error setting up notifiers, using desktop notifications: %v", err)
+		r, _ = notifiers.NewRouter(nil, nil, assets.PlanePath())
+	}
+
+	return r
+}
+
+// notify takes an Alert and sends it to the notifiers routed for its kind, logging any that fail
+func notify(alert types.Alert) {
+	r := router
+	if r == nil {
+		r = newRouter(types.SaveData{})
+	}
+
+	if err := r.Send(alert); err != nil {
+		log.Printf("Error sending notification: %v", err)
+	}
 }
 
 // notifyIfNew takes the slice of PlaneInfo
 // For each one, if it has been seen before then it continues without doing anything
 // It it has not been seen before it sends a notification to the user, and updates the save progress
+// Notifications are grouped and capped according to the Batching settings
+// During quiet hours the progress is still updated, but the plane is kept for a summary once they are over
+// Outside the daylight or night the sun rule allows, the progress is updated but no notification is sent
 func notifyIfNew(planeInfos []types.PlaneInfo) {
 	saveData, err := GetSave(savePath)
 	if err != nil {
 		log.Printf("Error getting saved stats: %v", err)
 	}
 
+	quiet := schedule.IsQuiet(saveData.Schedule, time.Now())
+	allowed := sun.Allowed(saveData.SunRule, saveData.Position, time.Now())
+	if !quiet {
+		if missedPlanes := missed.Flush(); len(missedPlanes) > 0 {
+			notify(types.Alert{Kind: notifiers.Summary, Title: i18n.T("notify.quiet_over_title"), Message: schedule.Summary(missedPlanes), Time: time.Now(), Planes: missedPlanes})
+		}
+	}
+
 	newPlanes := 0
 	for _, p := range planeInfos {
 		if slices.Contains(saveData.Progress.Callsigns, p.Callsign) {
@@ -155,12 +563,40 @@ func notifyIfNew(planeInfos []types.PlaneInfo) {
 		} else {
 			newPlanes++
 			SaveProgress(savePath, p)
-			messageBody := fmt.Sprintf("%v \n ↑ %v → %v 🧭 %v \nTotal seen: %v", p.Callsign, p.Baro_Altitude, p.Velocity, p.True_Track, saveData.SeenCount+newPlanes)
-			err = beeep.Notify("Plane Spotted!", messageBody, "assets/plane.png")
-			if err != nil {
-				log.Printf("Error sending notification: %v", err)
+			if quiet {
+				missed.Add(p)
+				continue
+			}
+			if !allowed {
+				continue
+			}
+			data := templates.Data{
+				PlaneInfo: formatters.ApplyUnits(p, saveData.Units),
+				Units:     saveData.Units,
+				Backlit:   sun.Backlit(saveData.Position, p.Look, time.Now()),
+				Profile:   saveData.ActiveProfile,
+				TotalSeen: saveData.SeenCount + newPlanes,
+				Time:      time.Now(),
+			}
+			if p.State.Has_Position {
+				planePosition := types.Position{Latitude: p.State.Latitude, Longitude: p.State.Longitude}
+				data.DistanceKm = geo.DistanceKm(saveData.Position, planePosition)
+				data.Bearing = geo.Bearing(saveData.Position, planePosition)
+			}
+			if i := profiles.Find(saveData.Profiles, saveData.ActiveProfile); i != -1 {
+				data.ProfileSeen = saveData.Profiles[i].Progress.SeenCount + newPlanes
 			}
 
+			title, messageBody, err := templates.Render(saveData.Templates, data)
+			if err != nil {
+				log.Printf("Error rendering notification template, using the default: %v", err)
+				title, messageBody, _ = templates.Render(types.MessageTemplates{}, data)
+			}
+			batcher.Add(p, title, messageBody)
 		}
 	}
+
+	for _, n := range batcher.Due(saveData.Batching, time.Now()) {
+		notify(types.Alert{Kind: notifiers.Spotted, Title: n.Title, Message: n.Message, Time: time.Now(), Planes: n.Planes})
+	}
 }
@@ -2,11 +2,22 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/auth"
+	"planespotter/helpers/budget"
+	"planespotter/helpers/fetch"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/schedule"
+	"planespotter/helpers/states"
+	"planespotter/helpers/sun"
 	"planespotter/helpers/types"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -53,10 +64,12 @@ func TestParseResult(t *testing.T) {
 	expectedResult := types.PlaneInfo{
 		Icao24:        "testicao",
 		Callsign:      "testcallsign",
-		Baro_Altitude: "18227 ft",
+		Baro_Altitude: "18,227 ft",
 		On_Ground:     "true",
 		Velocity:      "887 kts",
 		True_Track:    "123°",
+		Vertical_Rate: "+155,317 ft/min",
+		State:         types.StateVector{Has_Position: true, Longitude: 1111.2222, Latitude: 3333.4444, Has_Baro_Altitude: true, Baro_Altitude: 5555.66, Has_Geo_Altitude: true, Geo_Altitude: 987.654, Has_Velocity: true, Velocity: 456.789, True_Track: 123.456, Has_Vertical_Rate: true, Vertical_Rate: 789.012},
 	}
 
 	res := parseResult(testApiResponse)
@@ -72,10 +85,12 @@ func TestParseResult(t *testing.T) {
 	expectedBadResult := types.PlaneInfo{
 		Icao24:        "N/A",
 		Callsign:      "testcallsign",
-		Baro_Altitude: "18227 ft",
+		Baro_Altitude: "18,227 ft",
 		On_Ground:     "true",
 		Velocity:      "887 kts",
 		True_Track:    "123°",
+		Vertical_Rate: "+155,317 ft/min",
+		State:         types.StateVector{Has_Position: true, Longitude: 1111.2222, Latitude: 3333.4444, Has_Baro_Altitude: true, Baro_Altitude: 5555.66, Has_Geo_Altitude: true, Geo_Altitude: 987.654, Has_Velocity: true, Velocity: 456.789, True_Track: 123.456, Has_Vertical_Rate: true, Vertical_Rate: 789.012},
 	}
 
 	res = parseResult(testBadApiResponse)
@@ -85,6 +100,8 @@ func TestParseResult(t *testing.T) {
 }
 
 func TestNotifyIfNew(t *testing.T) {
+	defer func(path string) { savePath = path }(savePath)
+	savePath = testSavePath
 	err := CreateSaveIfNotExists(testSavePath)
 	if err != nil {
 		t.Error(err)
@@ -94,7 +111,7 @@ func TestNotifyIfNew(t *testing.T) {
 		{
 			Icao24:        "N/A",
 			Callsign:      "testcallsign",
-			Baro_Altitude: "18227 ft",
+			Baro_Altitude: "18,227 ft",
 			On_Ground:     "true",
 			Velocity:      "887 kts",
 			True_Track:    "123°",
@@ -109,6 +126,57 @@ func TestNotifyIfNew(t *testing.T) {
 	}
 }
 
+func TestNotifyIfNewQuiet(t *testing.T) {
+	// Quiet all day by only notifying on the other days of the week
+	var days []string
+	for _, day := range schedule.Weekdays {
+		if day != time.Now().Weekday().String()[:3] {
+			days = append(days, day)
+		}
+	}
+	// notifyIfNew saves progress to savePath, so point it at the test save rather than a real one
+	defer func(path string) { savePath = path }(savePath)
+	savePath = testSavePath
+	SaveConfig(testSavePath, types.Config{Schedule: types.Schedule{Days: days}})
+
+	notifyIfNew([]types.PlaneInfo{{Icao24: "quieticao", Callsign: "QUIET1"}})
+
+	s, err := GetSave(testSavePath)
+	assert.NoError(t, err)
+	assert.Contains(t, s.Callsigns, "QUIET1", "Progress should be saved while quiet")
+	assert.Len(t, missed.Flush(), 1)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNotifyIfNewSunRule(t *testing.T) {
+	defer func(path string) { savePath = path }(savePath)
+	savePath = testSavePath
+
+	// Only notify in the part of the day it isn't
+	rule := sun.NightOnly
+	if !sun.IsDaylight(types.Position{}, time.Now()) {
+		rule = sun.DaylightOnly
+	}
+	SaveConfig(testSavePath, types.Config{SunRule: rule})
+
+	notifyIfNew([]types.PlaneInfo{{Icao24: "darkicao", Callsign: "DARK1"}})
+
+	s, err := GetSave(testSavePath)
+	assert.NoError(t, err)
+	assert.Contains(t, s.Callsigns, "DARK1", "Progress should be saved outside the sun rule")
+	assert.Equal(t, 1, s.SeenCount)
+	assert.Empty(t, missed.Flush())
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestUpdatePlanes(t *testing.T) {
 	testApiPlane := []interface{}{
 		"testicao", "testcallsign", "testorigincountry", 1234, 5678, 1111.2222, 3333.4444, 5555.66, true, 456.789, 123.456, 789.012, []int{1, 2, 3}, 987.654, "testsquawk", false, 1, 2,
@@ -127,7 +195,7 @@ func TestUpdatePlanes(t *testing.T) {
 	}))
 	defer server.Close()
 
-	res, err := updatePlanes(server.URL)
+	res, err := updatePlanes(server.URL, 1)
 	if err != nil {
 		t.Error(err)
 	}
@@ -136,29 +204,76 @@ func TestUpdatePlanes(t *testing.T) {
 		{
 			Icao24:        "testicao",
 			Callsign:      "testcallsign",
-			Baro_Altitude: "18227 ft",
+			Baro_Altitude: "18,227 ft",
 			On_Ground:     "true",
 			Velocity:      "887 kts",
-			True_Track:    "123°"},
+			True_Track:    "123°",
+			Vertical_Rate: "+155,317 ft/min",
+			Category:      "light",
+			State:         types.StateVector{Has_Position: true, Longitude: 1111.2222, Latitude: 3333.4444, Has_Baro_Altitude: true, Baro_Altitude: 5555.66, Has_Geo_Altitude: true, Geo_Altitude: 987.654, Has_Velocity: true, Velocity: 456.789, True_Track: 123.456, Has_Vertical_Rate: true, Vertical_Rate: 789.012}},
 	}
 
 	assert.Equal(t, expectedResult, res)
 }
 
+func TestUpdatePlanesStale(t *testing.T) {
+	defer func() { feed = states.NewFeed(states.DefaultMaxAgeSeconds * time.Second) }()
+	feed = states.NewFeed(time.Minute)
+
+	recent := []interface{}{"4007f5", "BAW12", "United Kingdom", 1760875195, 1760875198, -0.09, 51.51, 1067, false, 92.7, 268, -3.5, nil, 1100, "1000", false, 0, 4}
+	stale := []interface{}{"400a0b", "EZY34", "United Kingdom", 1760875000, 1760875000, -0.2, 51.4, 2000, false, 120, 90, 0, nil, 2050, "2000", false, 0, 0}
+	resBody, err := json.Marshal(types.Result{Time: 1760875200, PlaneResults: [][]interface{}{recent, stale}})
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(resBody)
+	}))
+	defer server.Close()
+
+	res, err := updatePlanes(server.URL, 1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "4007f5", res[0].Icao24)
+	assert.Equal(t, "large", res[0].Category)
+	assert.Equal(t, time.Unix(1760875200, 0), feed.Latest(server.URL))
+
+	// The same response again has nothing new in it
+	res, err = updatePlanes(server.URL, 1)
+	assert.ErrorIs(t, err, states.ErrUnchanged)
+	assert.Len(t, res, 1)
+	_, err = updateAreas([]watchQuery{{url: server.URL, watchlist: true}})
+	assert.ErrorIs(t, err, states.ErrUnchanged)
+
+	// But if any query has something new the check goes ahead
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"time":1760875210,"states":[]}`))
+	}))
+	defer other.Close()
+	res, err = updateAreas([]watchQuery{{url: server.URL, watchlist: true}, {url: other.URL, watchlist: true}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
 func TestUpdatePlanesErrors(t *testing.T) {
 	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}))
 	defer failingServer.Close()
 
-	_, err := updatePlanes(failingServer.URL)
-	assert.Error(t, err)
+	_, err := updatePlanes(failingServer.URL, 1)
+	assert.Equal(t, fetch.Server, fetch.KindOf(err))
+
+	closedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedServer.Close()
+
+	_, err = updatePlanes(closedServer.URL, 1)
+	assert.Equal(t, fetch.Network, fetch.KindOf(err))
 
 	missingResBodyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
-	_, err = updatePlanes(missingResBodyServer.URL)
+	_, err = updatePlanes(missingResBodyServer.URL, 1)
 	assert.Error(t, err)
 
 	badlyFormedResBodyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -166,6 +281,274 @@ func TestUpdatePlanesErrors(t *testing.T) {
 		w.Write([]byte{})
 	}))
 
-	_, err = updatePlanes(badlyFormedResBodyServer.URL)
+	_, err = updatePlanes(badlyFormedResBodyServer.URL, 1)
+	assert.Equal(t, fetch.Parse, fetch.KindOf(err))
+}
+
+func TestRetryDelay(t *testing.T) {
+	backoff := retryBackoff
+	retryBackoff.Rand = func() float64 { return 1 }
+	defer func() { retryBackoff = backoff }()
+
+	network := &fetch.Error{Kind: fetch.Network, Err: errors.New("connection refused")}
+	rateLimit := &fetch.Error{Kind: fetch.RateLimit, Status: 429}
+	auth := &fetch.Error{Kind: fetch.Auth, Status: 401}
+	tests := []struct {
+		err        error
+		failures   int
+		interval   time.Duration
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		// The first retry comes well before the next check would have
+		{err: network, failures: 1, interval: time.Hour, expected: 5 * time.Second},
+		{err: network, failures: 3, interval: time.Hour, expected: 20 * time.Second},
+		{err: network, failures: 20, interval: time.Minute, expected: 5 * time.Minute},
+		// The API's Retry-After is always waited out
+		{err: rateLimit, failures: 1, interval: time.Minute, retryAfter: 90 * time.Second, expected: 90 * time.Second},
+		{err: rateLimit, failures: 1, interval: time.Minute, expected: 5 * time.Second},
+		{err: auth, failures: 1, interval: time.Minute, expected: 5 * time.Minute},
+		{err: auth, failures: 1, interval: time.Hour, expected: time.Hour},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, retryDelay(test.err, test.failures, test.interval, test.retryAfter), test.err.Error())
+	}
+}
+
+func TestErrorText(t *testing.T) {
+	i18n.Set("en")
+	assert.Equal(t, "can't reach the API", errorText(&fetch.Error{Kind: fetch.Network}))
+	assert.Equal(t, "out of API credits", errorText(&fetch.Error{Kind: fetch.RateLimit, Status: 429}))
+	assert.Equal(t, "something else", errorText(errors.New("something else")))
+}
+
+func TestUpdatePlanesAuth(t *testing.T) {
+	tokens := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		fmt.Fprintf(w, `{"access_token":"token-%v","token_type":"Bearer","expires_in":1800}`, tokens)
+	}))
+	defer tokenServer.Close()
+
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"time":0,"states":[]}`))
+	}))
+	defer server.Close()
+
+	apiAuth = auth.New(types.ApiAuth{ClientId: "spotter-api-client", ClientSecret: "s3cret", TokenUrl: tokenServer.URL})
+	defer func() { apiAuth = auth.None{} }()
+
+	// A rejected token is replaced on the next request
+	_, err := updatePlanes(server.URL, 1)
+	assert.Equal(t, fetch.Auth, fetch.KindOf(err))
+	_, err = updatePlanes(server.URL, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authorization)
+
+	apiAuth = auth.New(types.ApiAuth{Username: "spotter", Password: "pass"})
+	_, err = updatePlanes(server.URL, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Basic c3BvdHRlcjpwYXNz", authorization[2])
+}
+
+func TestUpdatePlanesRateLimited(t *testing.T) {
+	defer func() { credits = budget.NewTracker(budget.AnonymousCredits) }()
+	credits = budget.NewTracker(budget.AnonymousCredits)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Rate-Limit-Retry-After-Seconds", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := updatePlanes(server.URL, 1)
+	assert.ErrorIs(t, err, budget.ErrRateLimited)
+	assert.Equal(t, fetch.RateLimit, fetch.KindOf(err))
+	assert.InDelta(t, 120, credits.Wait(time.Now()).Seconds(), 1)
+	assert.Equal(t, 0, credits.Remaining(time.Now()))
+
+	remaining := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Rate-Limit-Remaining", "3120")
+		w.Write([]byte(`{"time":0,"states":[]}`))
+	}))
+	defer remaining.Close()
+
+	_, err = updatePlanes(remaining.URL, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3120, credits.Remaining(time.Now()), "The API's count already includes the request")
+
+	// Without the API's count the cost is taken off our own
+	counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"time":0,"states":[]}`))
+	}))
+	defer counted.Close()
+
+	_, err = updatePlanes(counted.URL, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3117, credits.Remaining(time.Now()))
+}
+
+func TestWatchQueries(t *testing.T) {
+	saveData := types.SaveData{Config: types.Config{
+		ActiveProfile:  "Home",
+		Position:       types.Position{Latitude: 51.5, Longitude: -0.1},
+		SpotDistanceKm: 20,
+		Profiles: []types.Profile{
+			{Name: "Home", Watch: true},
+			{Name: "Airport", Position: types.Position{Latitude: 51.47, Longitude: -0.45}, SpotDistanceKm: 10, Watch: true},
+			{Name: "Faraway", Position: types.Position{Latitude: 40.6, Longitude: -73.8}, SpotDistanceKm: 20, Watch: true},
+			{Name: "Office", Position: types.Position{Latitude: 53.4, Longitude: -2.2}, SpotDistanceKm: 20},
+		},
+	}}
+
+	queries := watchQueries("http://localhost", saveData)
+	assert.Len(t, queries, 2)
+	assert.Len(t, queries[0].areas, 2)
+	assert.Equal(t, "Faraway", queries[1].areas[0].Name)
+
+	saveData.Profiles = nil
+	queries = watchQueries("http://localhost", saveData)
+	assert.Equal(t, []watchQuery{{url: "http://localhost", areas: []types.Area{{Name: "Home", Position: saveData.Position, SpotDistanceKm: 20}}, credits: 1}}, queries)
+}
+
+func TestPlanChecks(t *testing.T) {
+	defer func() { credits = budget.NewTracker(budget.AnonymousCredits) }()
+	saveData := types.SaveData{Config: types.Config{
+		Position:         types.Position{Latitude: 60, Longitude: 10},
+		SpotDistanceKm:   500,
+		CheckFreqSeconds: 10,
+	}}
+
+	// Plenty of credits, so the check frequency is used
+	credits = budget.NewTracker(1000000)
+	queries, interval := planChecks("http://localhost", saveData, 10*time.Second)
+	assert.Equal(t, 10*time.Second, interval)
+	assert.Equal(t, 3, queries[0].credits)
+
+	// Too few, so checks are spread over the rest of the day
+	credits = budget.NewTracker(3)
+	_, interval = planChecks("http://localhost", saveData, 10*time.Second)
+	assert.Equal(t, budget.NextReset(time.Now()).Sub(time.Now()).Round(time.Second), interval.Round(time.Second))
+
+	// Or the area is shrunk to cheaper queries first
+	saveData.Budget.Adapt = budget.Area
+	queries, _ = planChecks("http://localhost", saveData, 10*time.Second)
+	assert.Equal(t, 1, queries[0].credits)
+	assert.Less(t, queries[0].areas[0].SpotDistanceKm, 500)
+	assert.NotEqual(t, "http://localhost", queries[0].url)
+
+	saveData.Budget.Adapt = budget.Off
+	_, interval = planChecks("http://localhost", saveData, 10*time.Second)
+	assert.Equal(t, 10*time.Second, interval)
+
+	// Waiting when the API asks, whatever the budget
+	credits.RetryAfter("60", time.Now())
+	_, interval = planChecks("http://localhost", saveData, 10*time.Second)
+	assert.InDelta(t, 60, interval.Seconds(), 1)
+
+	// A watchlist is its own query without a box, costed as a global query and never shrunk
+	credits = budget.NewTracker(1000000)
+	saveData.States.Icao24 = []string{"4007f5"}
+	queries, _ = planChecks("http://localhost", saveData, 10*time.Second)
+	assert.Len(t, queries, 2)
+	assert.Equal(t, "http://localhost", queries[0].url)
+	assert.True(t, queries[1].watchlist)
+	assert.Equal(t, areas.GlobalCredits, queries[1].credits)
+	assert.Equal(t, "https://opensky-network.org/api/states/all?icao24=4007f5", queries[1].url)
+}
+
+func TestBudgetText(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "8,000 API credits left today, enough for today", budgetText(8000, 1, 10*time.Second, 10*time.Second, 0, now))
+	assert.Equal(t, "360 API credits left today, running out at 18:00", budgetText(360, 1, time.Minute, time.Minute, 0, now))
+	assert.Equal(t, "360 API credits left today, enough for today\nChecking every 2m0s to make them last", budgetText(360, 1, 2*time.Minute, time.Minute, 0, now))
+	assert.Equal(t, "0 API credits left today, no more until 00:00\nThe API asked to wait 1m30s", budgetText(0, 1, 90*time.Second, time.Minute, 90*time.Second, now))
+}
+
+func TestLookFrom(t *testing.T) {
+	observer := types.Position{Latitude: 51.5, Longitude: -0.1}
+	planeInfos := []types.PlaneInfo{
+		{Callsign: "NORTH1", State: types.StateVector{Has_Position: true, Latitude: 51.6, Longitude: -0.1, Has_Baro_Altitude: true, Baro_Altitude: 3000}},
+		{Callsign: "NOPOS1"},
+	}
+
+	res := lookFrom(observer, planeInfos)
+	assert.InDelta(t, 0, res[0].Look.AzimuthDeg, 0.1)
+	assert.InDelta(t, 15, res[0].Look.ElevationDeg, 0.5)
+	assert.Equal(t, types.LookAngles{}, res[1].Look)
+}
+
+func TestWithUnits(t *testing.T) {
+	planeInfos := []types.PlaneInfo{{Callsign: "HIGH1", State: types.StateVector{Has_Baro_Altitude: true, Baro_Altitude: 11278, Has_Velocity: true, Velocity: 240}}}
+
+	res := withUnits(planeInfos, types.Units{Altitude: "FL", Speed: "km/h"})
+	assert.Equal(t, "FL370", res[0].Baro_Altitude)
+	assert.Equal(t, "864 km/h", res[0].Velocity)
+	assert.Equal(t, "N/A", res[0].Vertical_Rate)
+}
+
+func TestPassQuery(t *testing.T) {
+	saveData := types.SaveData{Config: types.Config{Position: types.Position{Latitude: 0, Longitude: 0}}}
+	assert.Equal(t, "", passQuery(saveData))
+
+	saveData.PassAlert = types.PassAlert{WithinKm: 6, Minutes: 7}
+	// 6km plus 7 minutes at 15km a minute is a radius of 111km, about a degree either way
+	assert.Contains(t, passQuery(saveData), "?lamax=0.9991&lamin=-0.9991&lomax=0.9971&lomin=-0.9971")
+}
+
+func TestUpdateAreas(t *testing.T) {
+	atHome := []interface{}{
+		"homeicao", "HOME1", "testorigincountry", 1234, 5678, -0.1, 51.5, 5555.66, false, 456.789, 123.456, 789.012, nil, 987.654, "testsquawk", false, 1, 2,
+	}
+	atAirport := []interface{}{
+		"airporticao", "AIRPORT1", "testorigincountry", 1234, 5678, -0.45, 51.47, 5555.66, false, 456.789, 123.456, 789.012, nil, 987.654, "testsquawk", false, 1, 2,
+	}
+
+	resBody, err := json.Marshal(types.Result{PlaneResults: [][]interface{}{atHome, atAirport}})
+	if err != nil {
+		t.Error("Error marshalling test data")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(resBody)
+	}))
+	defer server.Close()
+
+	home := types.Area{Name: "Home", Position: types.Position{Latitude: 51.5, Longitude: -0.1}, SpotDistanceKm: 20}
+	airport := types.Area{Name: "Airport", Position: types.Position{Latitude: 51.47, Longitude: -0.45}, SpotDistanceKm: 10}
+
+	// Both queries return both planes, but each should only be included once
+	res, err := updateAreas([]watchQuery{{url: server.URL, areas: []types.Area{home, airport}}, {url: server.URL, areas: []types.Area{airport}}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "Home", res[0].Area)
+	assert.Equal(t, "Airport", res[1].Area)
+
+	// Watchlisted planes outside every area are kept too, while those inside one keep its name
+	// The watchlist query has no box, so without a count from the API it is charged as a global query
+	defer func() { credits = budget.NewTracker(budget.AnonymousCredits) }()
+	credits = budget.NewTracker(100)
+	far := types.Area{Name: "Far", Position: types.Position{Latitude: 0, Longitude: 0}, SpotDistanceKm: 10}
+	res, err = updateAreas([]watchQuery{{url: server.URL, areas: []types.Area{home, far}, credits: 1}, {url: server.URL, watchlist: true, credits: areas.GlobalCredits}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "Home", res[0].Area)
+	assert.Equal(t, states.Watchlist, res[1].Area)
+	assert.Equal(t, 100-1-areas.GlobalCredits, credits.Remaining(time.Now()))
+
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	_, err = updateAreas([]watchQuery{{url: server.URL, areas: []types.Area{home}}, {url: failingServer.URL, areas: []types.Area{airport}}})
 	assert.Error(t, err)
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"image/color"
+	"log"
+	"math"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/basemap"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/types"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+const mapSize = 500
+const defaultBasemapDir = "basemap"
+
+// mapView holds what is needed to redraw aircraft on an open map window
+type mapView struct {
+	centre      types.Position
+	projection  string
+	pixelsPerKm float64
+	aircraft    *fyne.Container
+}
+
+var currentMap *mapView
+var currentMapLock sync.Mutex
+
+var layerColours = map[string]color.Color{
+	"coastlines": color.NRGBA{R: 0x4a, G: 0x90, B: 0xd9, A: 0xff},
+	"borders":    color.NRGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff},
+	"runways":    color.NRGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff},
+	"airports":   color.NRGBA{R: 0xe6, G: 0x8a, B: 0x00, A: 0xff},
+}
+var planeColour = color.NRGBA{R: 0xd9, G: 0x30, B: 0x30, A: 0xff}
+var trailColour = color.NRGBA{R: 0xd9, G: 0x30, B: 0x30, A: 0x80}
+var areaColour = color.NRGBA{R: 0x30, G: 0xa0, B: 0x30, A: 0xff}
+
+// MapWindow takes the Fyne App and SaveData, and creates a window showing the offline basemap layers
+// around the configured position with the spot area and latest aircraft drawn on top
+// Returns the Fyne Window
+func MapWindow(app fyne.App, saveData types.SaveData) fyne.Window {
+	window := app.NewWindow(i18n.T("ui.map_title"))
+	window.Resize(fyne.NewSize(mapSize, mapSize))
+	window.SetFixedSize(true)
+
+	dir := saveData.Basemap.Directory
+	if dir == "" {
+		dir = defaultBasemapDir
+	}
+	layers, err := basemap.LoadLayers(dir)
+	if err != nil {
+		log.Printf("Error loading basemap: %v", err)
+	}
+
+	// Show a little more than the spot area so planes can be seen approaching
+	viewRadiusKm := math.Max(float64(saveData.SpotDistanceKm)*1.5, 1)
+	view := &mapView{
+		centre:      saveData.Position,
+		projection:  saveData.Basemap.Projection,
+		pixelsPerKm: mapSize / 2 / viewRadiusKm,
+		aircraft:    container.NewWithoutLayout(),
+	}
+
+	background := canvas.NewRectangle(color.Transparent)
+	background.SetMinSize(fyne.NewSize(mapSize, mapSize))
+
+	basemapLayer := container.NewWithoutLayout(view.layerObjects(layers)...)
+	for _, o := range view.spotAreaObjects(types.Area{Position: saveData.Position, SpotDistanceKm: saveData.SpotDistanceKm, Shape: saveData.Shape}) {
+		basemapLayer.Add(o)
+	}
+
+	currentMapLock.Lock()
+	currentMap = view
+	currentMapLock.Unlock()
+	window.SetOnClosed(func() {
+		currentMapLock.Lock()
+		currentMap = nil
+		currentMapLock.Unlock()
+	})
+
+	window.SetContent(container.NewStack(background, basemapLayer, view.aircraft))
+	return window
+}
+
+// updateMap takes the latest slice of PlaneInfo and the open tracks, and redraws the aircraft and their trails
+// on the map, if a map window is open
+func updateMap(planeInfos []types.PlaneInfo, trails []types.Sighting) {
+	currentMapLock.Lock()
+	defer currentMapLock.Unlock()
+	if currentMap == nil {
+		return
+	}
+
+	var objects []fyne.CanvasObject
+	for _, s := range trails {
+		for i := 1; i < len(s.Track); i++ {
+			segment := canvas.NewLine(trailColour)
+			segment.StrokeWidth = 1
+			segment.Position1 = currentMap.toScreen(types.Position{Latitude: s.Track[i-1].Latitude, Longitude: s.Track[i-1].Longitude})
+			segment.Position2 = currentMap.toScreen(types.Position{Latitude: s.Track[i].Latitude, Longitude: s.Track[i].Longitude})
+			objects = append(objects, segment)
+		}
+	}
+
+	for _, p := range planeInfos {
+		if !p.State.Has_Position {
+			continue
+		}
+		pos := currentMap.toScreen(types.Position{Latitude: p.State.Latitude, Longitude: p.State.Longitude})
+
+		marker := canvas.NewCircle(planeColour)
+		marker.Move(fyne.NewPos(pos.X-3, pos.Y-3))
+		marker.Resize(fyne.NewSize(6, 6))
+
+		label := canvas.NewText(p.Callsign, planeColour)
+		label.TextSize = 10
+		label.Move(fyne.NewPos(pos.X+5, pos.Y-6))
+
+		objects = append(objects, marker, label)
+	}
+
+	currentMap.aircraft.Objects = objects
+	currentMap.aircraft.Refresh()
+}
+
+// spotAreaObjects takes the active Area and returns canvas objects outlining it: a circle for a radius,
+// or lines along a polygon's edges or a corridor's centreline
+func (m *mapView) spotAreaObjects(area types.Area) []fyne.CanvasObject {
+	if !areas.IsShaped(area) {
+		spotArea := canvas.NewCircle(color.Transparent)
+		spotArea.StrokeColor = areaColour
+		spotArea.StrokeWidth = 1
+		spotRadius := float32(float64(area.SpotDistanceKm) * m.pixelsPerKm)
+		spotArea.Move(fyne.NewPos(mapSize/2-spotRadius, mapSize/2-spotRadius))
+		spotArea.Resize(fyne.NewSize(spotRadius*2, spotRadius*2))
+		return []fyne.CanvasObject{spotArea}
+	}
+
+	points := area.Shape.Points
+	if area.Shape.Type == areas.Polygon {
+		points = append(points[:len(points):len(points)], points[0])
+	}
+
+	var objects []fyne.CanvasObject
+	for i := 1; i < len(points); i++ {
+		edge := canvas.NewLine(areaColour)
+		edge.StrokeWidth = 1
+		edge.Position1 = m.toScreen(points[i-1])
+		edge.Position2 = m.toScreen(points[i])
+		objects = append(objects, edge)
+	}
+
+	return objects
+}
+
+// layerObjects takes the loaded basemap layers and returns canvas objects for every line segment and point
+// that falls within the map, coloured by layer
+func (m *mapView) layerObjects(layers []basemap.Layer) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	for _, layer := range layers {
+		colour, ok := layerColours[layer.Name]
+		if !ok {
+			colour = layerColours["borders"]
+		}
+
+		for _, feature := range layer.Features {
+			for _, line := range feature.Lines {
+				for i := 1; i < len(line); i++ {
+					from, to := m.toScreen(line[i-1]), m.toScreen(line[i])
+					if !onMap(from) && !onMap(to) {
+						continue
+					}
+					segment := canvas.NewLine(colour)
+					segment.StrokeWidth = 1
+					segment.Position1 = from
+					segment.Position2 = to
+					objects = append(objects, segment)
+				}
+			}
+
+			for _, point := range feature.Points {
+				pos := m.toScreen(point)
+				if !onMap(pos) {
+					continue
+				}
+				marker := canvas.NewRectangle(colour)
+				marker.Move(fyne.NewPos(pos.X-2, pos.Y-2))
+				marker.Resize(fyne.NewSize(4, 4))
+				objects = append(objects, marker)
+
+				if feature.Name != "" {
+					label := canvas.NewText(feature.Name, colour)
+					label.TextSize = 9
+					label.Move(fyne.NewPos(pos.X+4, pos.Y-5))
+					objects = append(objects, label)
+				}
+			}
+		}
+	}
+
+	return objects
+}
+
+// toScreen takes a Position and returns its pixel position on the map, with north up and the centre in the middle
+func (m *mapView) toScreen(pos types.Position) fyne.Position {
+	p := basemap.Project(m.projection, m.centre, pos)
+	return fyne.NewPos(
+		float32(mapSize/2+p.X*m.pixelsPerKm),
+		float32(mapSize/2-p.Y*m.pixelsPerKm),
+	)
+}
+
+// onMap takes a pixel position and returns true if it is within the map window
+func onMap(pos fyne.Position) bool {
+	return pos.X >= 0 && pos.X <= mapSize && pos.Y >= 0 && pos.Y <= mapSize
+}
@@ -7,26 +7,73 @@ import (
 	"log"
 	"net/url"
 	"os"
-	"planespotter/helpers/formatters"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/profiles"
+	"planespotter/helpers/secrets"
+	"planespotter/helpers/states"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/tracks"
 	"planespotter/helpers/types"
+	"planespotter/helpers/validate"
 
 	"golang.org/x/exp/slices"
 )
 
 // InitSaveData takes a savePath and loads save data from it
-// It uses the basic configuration to create the request URL (lat, long, add auth to URL)
+// It uses the basic configuration to create the request URL (data source, lat, long)
 // It returns the built URL and the populated saveData
 func InitSaveData(savePath string) (string, types.SaveData) {
 	saveData, err := GetSave(savePath)
 	if err != nil {
 		log.Println("Error loading save file")
 	}
+	i18n.Set(saveData.Language)
+	saveData.ApiAuth = loadSecrets(saveData.Config)
 
-	sa := CalculateSearchArea(saveData.Config.Position, saveData.Config.SpotDistanceKm)
-	searchUrl, err := url.Parse(baseUrl)
+	// A hand edited save can hold anything, so problems are logged and checks are never more often than allowed
+	if err := validate.Config(saveData.Config); err != nil {
+		log.Printf("Problems with the settings in %v:\n%v", savePath, err)
+	}
+	if minimum := validate.MinCheckFreq(saveData.ApiAuth); saveData.CheckFreqSeconds < minimum {
+		saveData.CheckFreqSeconds = minimum
+	}
+
+	sa := CalculateSearchArea(saveData.Config.Position, saveData.Config.SpotDistanceKm, saveData.Config.Shape)
+
+	return SearchUrl(saveData, sa), saveData
+}
+
+// loadSecrets takes a Config and returns its ApiAuth with the password and client secret from its secrets store,
+// logging any problem and leaving them as they are in the save if the store can't be read
+func loadSecrets(c types.Config) types.ApiAuth {
+	store, err := secrets.Open(c.Secrets, passphrase())
 	if err != nil {
-		log.Println("Error parsing request URL")
+		log.Printf("Error opening secrets: %v", err)
+		return c.ApiAuth
 	}
+	defer secrets.Close(store)
+
+	apiAuth, err := secrets.Load(store, c.ApiAuth)
+	if err != nil {
+		log.Printf("Error reading secrets: %v", err)
+	}
+	return apiAuth
+}
+
+// passphrase returns the passphrase for an encrypted secrets file, as entered or from the environment
+func passphrase() string {
+	if secretsPassphrase != "" {
+		return secretsPassphrase
+	}
+	return os.Getenv(secrets.PassphraseVariable)
+}
+
+// SearchUrl takes saveData and a SearchArea, and builds the request URL for the configured data source
+// (OpenSky unless another is set) searching that area, asking for categories if the States settings say so.
+// Credentials are sent in a header by apiAuth, so they never end up in the URL, logs or errors
+func SearchUrl(saveData types.SaveData, sa types.SearchArea) string {
+	searchUrl := sourceUrl(saveData)
 
 	queryParams := url.Values{
 		"lamin": {sa.LaMin},
@@ -34,13 +81,44 @@ func InitSaveData(savePath string) (string, types.SaveData) {
 		"lamax": {sa.LaMax},
 		"lomax": {sa.LoMax},
 	}
+	states.Params(queryParams, saveData.States)
+
+	searchUrl.RawQuery = queryParams.Encode()
+
+	return searchUrl.String()
+}
+
+// WatchlistUrl takes saveData and builds the request URL for only the watchlisted aircraft, wherever they are,
+// or returns "" if there is no watchlist
+func WatchlistUrl(saveData types.SaveData) string {
+	params := states.WatchlistParams(saveData.States)
+	if params == nil {
+		return ""
+	}
+
+	watchlistUrl := sourceUrl(saveData)
+	watchlistUrl.RawQuery = params.Encode()
+
+	return watchlistUrl.String()
+}
 
-	url := "https://" + saveData.ApiAuth.Username + ":" + saveData.ApiAuth.Password + "@" + searchUrl.String() + queryParams.Encode()
+// sourceUrl takes saveData and returns the URL of the configured data source, or OpenSky's if none is set
+func sourceUrl(saveData types.SaveData) *url.URL {
+	source := baseUrl
+	if saveData.DataSource != "" {
+		source = saveData.DataSource
+	}
+	sourceUrl, err := url.Parse(source)
+	if err != nil {
+		log.Println("Error parsing request URL")
+		sourceUrl = &url.URL{}
+	}
 
-	return url, saveData
+	return sourceUrl
 }
 
 // SaveProgress takes a savePath, increments the number of planes found and adds a callsign to the save data
+// The progress of the profile whose area the plane was found in is updated too, if there is one
 // Creates save if it doesn't already exist
 func SaveProgress(savePath string, p types.PlaneInfo) {
 	err := CreateSaveIfNotExists(savePath)
@@ -57,14 +135,54 @@ func SaveProgress(savePath string, p types.PlaneInfo) {
 		saveData.SeenCount += 1
 		saveData.Callsigns = append(saveData.Callsigns, p.Callsign)
 	}
+	profile, _ := profiles.Owner(saveData.Config, p.Area)
+	saveData.Config = profiles.AddCallsign(saveData.Config, profile, p.Callsign)
 
 	SaveToFile(savePath, saveData)
 
 }
 
+// SaveSightings takes a savePath and a slice of Sighting whose tracks have closed, works out how close each came
+// to the position of the profile whose area it was found in, and adds them to the save data tagged with that profile
+// Does nothing if there are no sightings, so the save isn't rewritten every check
+func SaveSightings(savePath string, sightings []types.Sighting) {
+	if len(sightings) == 0 {
+		return
+	}
+
+	err := CreateSaveIfNotExists(savePath)
+	if err != nil {
+		log.Printf("Error creating save: %v", err)
+	}
+
+	saveData, err := GetSave(savePath)
+	if err != nil {
+		log.Printf("Error getting current saved state")
+	}
+
+	for _, s := range sightings {
+		profile, position := profiles.Owner(saveData.Config, s.Profile)
+		closest, km, ok := tracks.ClosestApproach(s, position)
+		s.ClosestApproachKm = km
+		s.Period = sun.Period(position, s.FirstSeen)
+		if ok {
+			s.Period = sun.Period(position, closest.Time)
+		}
+		s.Profile = profile
+		saveData.Sightings = append(saveData.Sightings, s)
+	}
+
+	SaveToFile(savePath, saveData)
+}
+
 // SaveConfig takes a savePath, and updates the configuration elements (api auth, long/lat, check frequency, spot distance etc.)
+// The password and client secret are left out if they are kept in a secrets store
 // Creates save if it doesn't already exist
 func SaveConfig(savePath string, saveConfig types.Config) {
+	if saveConfig.Secrets.Backend != "" {
+		saveConfig.ApiAuth = secrets.Strip(saveConfig.ApiAuth)
+	}
+
 	err := CreateSaveIfNotExists(savePath)
 	if err != nil {
 		log.Printf("Error creating save: %v", err)
@@ -147,21 +265,22 @@ func CreateSaveIfNotExists(savePath string) error {
 	return nil
 }
 
-// CalculateSearchArea takes a Position and a spotDistanceKm
+// CalculateSearchArea takes a Position, a spotDistanceKm and the SpotShape
 // It calculates the minimum and maximum longitude and latitude to draw a 'box' with
 // degress longitude and latitude which is required for the API to search
+// For a polygon or corridor shape this is the smallest box around the shape
+// It is the same box areas.Box gives, so watched areas and the credits they cost match what is searched
 // Returns a SearchArea
-func CalculateSearchArea(position types.Position, spotDistanceKm int) types.SearchArea {
-	var sa types.SearchArea
-
-	// Calculate offsets either side of position
-	latSpotOffset := formatters.KmToLatitude(spotDistanceKm)
-	longSpotOffset := formatters.KmToLongitude(spotDistanceKm, position.Latitude)
-
-	// Finalise min and max long and lat by adding/subtracting offset
-	sa.LaMax = fmt.Sprintf("%.4f", position.Latitude+latSpotOffset)
-	sa.LaMin = fmt.Sprintf("%.4f", position.Latitude-latSpotOffset)
-	sa.LoMax = fmt.Sprintf("%.4f", position.Longitude+longSpotOffset)
-	sa.LoMin = fmt.Sprintf("%.4f", position.Longitude-longSpotOffset)
-	return sa
+func CalculateSearchArea(position types.Position, spotDistanceKm int, shape types.SpotShape) types.SearchArea {
+	return BoxSearchArea(areas.Box(types.Area{Position: position, SpotDistanceKm: spotDistanceKm, Shape: shape}))
+}
+
+// BoxSearchArea takes a BoundingBox and returns it as a SearchArea for the API
+func BoxSearchArea(box types.BoundingBox) types.SearchArea {
+	return types.SearchArea{
+		LaMin: fmt.Sprintf("%.4f", box.LaMin),
+		LaMax: fmt.Sprintf("%.4f", box.LaMax),
+		LoMin: fmt.Sprintf("%.4f", box.LoMin),
+		LoMax: fmt.Sprintf("%.4f", box.LoMax),
+	}
 }
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
+	"planespotter/helpers/secrets"
+	"planespotter/helpers/sun"
 	"planespotter/helpers/types"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -137,11 +141,202 @@ func TestGetSave(t *testing.T) {
 
 func TestCalculateSearchArea(t *testing.T) {
 	p := types.Position{Longitude: 50.0, Latitude: 49.0}
-	sa := types.SearchArea{LaMin: "48.9100", LaMax: "49.0900", LoMin: "49.9728", LoMax: "50.0272", Height: "", Width: "", Area: ""}
+	sa := types.SearchArea{LaMin: "48.9100", LaMax: "49.0900", LoMin: "49.8631", LoMax: "50.1369", Height: "", Width: "", Area: ""}
 
-	res := CalculateSearchArea(p, 10)
+	res := CalculateSearchArea(p, 10, types.SpotShape{})
 
 	assert.Less(t, res.LaMin, res.LaMax)
 	assert.Less(t, res.LoMin, res.LoMax)
 	assert.Equal(t, sa, res)
 }
+
+func TestCalculateSearchAreaShape(t *testing.T) {
+	p := types.Position{Longitude: 50.0, Latitude: 49.0}
+	polygon := types.SpotShape{Type: "polygon", Points: []types.Position{
+		{Latitude: 51.0, Longitude: -1.0},
+		{Latitude: 51.2, Longitude: -1.0},
+		{Latitude: 51.2, Longitude: -0.5},
+	}}
+	sa := types.SearchArea{LaMin: "51.0000", LaMax: "51.2000", LoMin: "-1.0000", LoMax: "-0.5000"}
+
+	res := CalculateSearchArea(p, 10, polygon)
+	assert.Equal(t, sa, res)
+}
+
+func TestSaveSightings(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	sightings := []types.Sighting{
+		{
+			Icao24:   "testicao",
+			Callsign: "testcallsign",
+			Track: []types.TrackPoint{
+				{Time: time.Date(2026, 10, 19, 16, 59, 0, 0, time.UTC), Latitude: 40.83061, Longitude: -73.935242},
+				{Time: time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC), Latitude: 40.73061, Longitude: -73.835242},
+			},
+		},
+	}
+
+	SaveSightings(testSavePath, sightings)
+
+	s, err := GetSave(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, s.Sightings, 1)
+	assert.Equal(t, "testicao", s.Sightings[0].Icao24)
+	assert.Len(t, s.Sightings[0].Track, 2)
+	assert.InDelta(t, 8.43, s.Sightings[0].ClosestApproachKm, 0.01)
+	assert.Equal(t, sun.Day, s.Sightings[0].Period)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveProgressWithProfile(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	SaveConfig(testSavePath, types.Config{
+		ActiveProfile: "Home",
+		Profiles:      []types.Profile{{Name: "Home"}, {Name: "Office", Progress: types.Progress{SeenCount: 1, Callsigns: []string{"othercallsign"}}}},
+	})
+
+	SaveProgress(testSavePath, types.PlaneInfo{Callsign: "testcallsign"})
+	SaveProgress(testSavePath, types.PlaneInfo{Callsign: "othercallsign"})
+
+	s, err := GetSave(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, types.Progress{SeenCount: 2, Callsigns: []string{"testcallsign", "othercallsign"}}, s.Progress)
+	assert.Equal(t, types.Progress{SeenCount: 2, Callsigns: []string{"testcallsign", "othercallsign"}}, s.Profiles[0].Progress)
+	assert.Equal(t, types.Progress{SeenCount: 1, Callsigns: []string{"othercallsign"}}, s.Profiles[1].Progress)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveWatchedProfiles(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Home is active in London, watching Manchester and Edinburgh as well
+	SaveConfig(testSavePath, types.Config{
+		ActiveProfile: "Home",
+		Position:      types.Position{Latitude: 51.5, Longitude: -0.1},
+		Profiles: []types.Profile{
+			{Name: "Home", Position: types.Position{Latitude: 51.5, Longitude: -0.1}},
+			{Name: "Manchester", Position: types.Position{Latitude: 53.35, Longitude: -2.27}, Watch: true},
+			{Name: "Edinburgh", Position: types.Position{Latitude: 55.95, Longitude: -3.36}, Watch: true},
+		},
+	})
+
+	SaveProgress(testSavePath, types.PlaneInfo{Callsign: "EZY34", Area: "Manchester"})
+	SaveProgress(testSavePath, types.PlaneInfo{Callsign: "LOG56", Area: "Edinburgh"})
+	SaveSightings(testSavePath, []types.Sighting{{
+		Icao24:   "abc123",
+		Callsign: "EZY34",
+		Profile:  "Manchester",
+		Track: []types.TrackPoint{
+			{Time: time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC), Latitude: 53.36, Longitude: -2.27},
+		},
+	}})
+
+	s, err := GetSave(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 2, s.SeenCount)
+	assert.Equal(t, []string{"EZY34", "LOG56"}, s.Callsigns)
+	assert.Equal(t, types.Progress{}, s.Profiles[0].Progress)
+	assert.Equal(t, types.Progress{SeenCount: 1, Callsigns: []string{"EZY34"}}, s.Profiles[1].Progress)
+	assert.Equal(t, types.Progress{SeenCount: 1, Callsigns: []string{"LOG56"}}, s.Profiles[2].Progress)
+
+	// Measured from Manchester, not London 260 km away, where the sun had already set
+	assert.Equal(t, "Manchester", s.Sightings[0].Profile)
+	assert.InDelta(t, 1.1, s.Sightings[0].ClosestApproachKm, 0.05)
+	assert.Equal(t, sun.Night, sun.Period(types.Position{Latitude: 51.5, Longitude: -0.1}, time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)))
+	assert.Equal(t, sun.Day, s.Sightings[0].Period)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWatchlistUrl(t *testing.T) {
+	assert.Equal(t, "", WatchlistUrl(types.SaveData{}))
+
+	saveData := types.SaveData{Config: types.Config{DataSource: "http://localhost:8080/api/states/all", States: types.States{Icao24: []string{"4007f5", "400a0b"}}}}
+	assert.Equal(t, "http://localhost:8080/api/states/all?icao24=4007f5&icao24=400a0b", WatchlistUrl(saveData))
+}
+
+func TestInitSaveDataSource(t *testing.T) {
+	err := CreateSaveIfNotExists(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+
+	url, _ := InitSaveData(testSavePath)
+	assert.Contains(t, url, baseUrl[len("https://"):]+"?lamax=")
+
+	SaveConfig(testSavePath, types.Config{DataSource: "http://localhost:8080/api/states/all", SpotDistanceKm: 10})
+	url, _ = InitSaveData(testSavePath)
+	assert.Equal(t, "http://localhost:8080/api/states/all?lamax=0.0900&lamin=-0.0900&lomax=0.0898&lomin=-0.0898", url)
+
+	SaveConfig(testSavePath, types.Config{SpotDistanceKm: 10, States: types.States{Extended: true, Icao24: []string{"4007f5"}}})
+	url, _ = InitSaveData(testSavePath)
+	assert.Contains(t, url, "?extended=1&lamax=")
+	assert.NotContains(t, url, "icao24")
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveDataSecrets(t *testing.T) {
+	secretsPath := filepath.Join(t.TempDir(), "secrets.enc")
+	t.Setenv(secrets.PassphraseVariable, "correct horse battery staple")
+	c := types.Config{
+		Position:         types.Position{Latitude: 48, Longitude: 2},
+		ApiAuth:          types.ApiAuth{Username: "blah", Password: "fluff"},
+		CheckFreqSeconds: 60,
+		SpotDistanceKm:   20,
+		Secrets:          types.Secrets{Backend: secrets.File, File: secretsPath},
+	}
+	assert.NoError(t, saveSecrets(c))
+	SaveConfig(testSavePath, c)
+
+	// The password is only in the secrets file
+	content, err := os.ReadFile(testSavePath)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "fluff")
+
+	_, saveData := InitSaveData(testSavePath)
+	assert.Equal(t, types.ApiAuth{Username: "blah", Password: "fluff"}, saveData.ApiAuth)
+
+	t.Setenv(secrets.Variable(secrets.PasswordKey), "from-env")
+	_, saveData = InitSaveData(testSavePath)
+	assert.Equal(t, "from-env", saveData.ApiAuth.Password)
+
+	err = os.Remove(testSavePath)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -1,47 +1,177 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"planespotter/assets"
+	"planespotter/helpers/areas"
+	"planespotter/helpers/basemap"
+	"planespotter/helpers/batch"
+	"planespotter/helpers/budget"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/geocode"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/profiles"
+	"planespotter/helpers/schedule"
+	"planespotter/helpers/secrets"
+	"planespotter/helpers/states"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/templates"
 	"planespotter/helpers/types"
+	"planespotter/helpers/validate"
 	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 )
 
 // InitUi takes the url, savePath and Save Data to form the main sections of the Fyne UI.
 // Returns the Fyne App and Fyne Window
 func InitUi(url, savePath string, saveData types.SaveData) (fyne.App, fyne.Window) {
-	icon, _ := fyne.LoadResourceFromPath("assets/plane.png")
+	icon := fyne.NewStaticResource("plane.png", assets.Plane)
 	app := app.NewWithID("Planespotter")
 	app.SetIcon(icon)
 	window := WindowSetup(app, icon)
 
-	title := widget.NewLabelWithStyle("Configuration", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
-	settingsForm := FormSetup(savePath, saveData)
+	window.SetContent(ContentSetup(app, window, url, savePath, saveData))
+	return app, window
+}
+
+// ContentSetup takes the Fyne App and Window, the url, savePath and Save Data, and creates the window's content:
+// profiles, the settings form, start/stop/map buttons and status. Returns a Fyne Container to set as the window content
+func ContentSetup(app fyne.App, window fyne.Window, url, savePath string, saveData types.SaveData) *fyne.Container {
+	title := widget.NewLabelWithStyle(i18n.T("ui.configuration"), fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	// Loading the save reads the secrets, and the keyring may ask to be unlocked, so it is done off the UI goroutine
+	reload := func() {
+		go func() {
+			url, saveData := InitSaveData(savePath)
+			window.SetContent(ContentSetup(app, window, url, savePath, saveData))
+		}()
+	}
+	profileRow := ProfileSetup(savePath, saveData, reload)
+	settingsForm := FormSetup(savePath, saveData, reload)
 
-	startButton := widget.NewButton("Start", func() {
+	startButton := widget.NewButton(i18n.T("ui.start"), func() {
 		if !started {
 			startUpdateLoop(url, saveData)
 		}
 	})
-	stopButton := widget.NewButton("Stop", func() {
+	stopButton := widget.NewButton(i18n.T("ui.stop"), func() {
 		if started {
 			stopUpdateLoop()
 		}
 	})
 
 	if started {
-		status.Set(StartedText)
+		status.Set(i18n.T(StartedText))
 	} else {
-		status.Set(StoppedText)
+		status.Set(i18n.T(StoppedText))
 	}
+	mapButton := widget.NewButton(i18n.T("ui.map"), func() {
+		saveData, err := GetSave(savePath)
+		if err != nil {
+			log.Printf("Error getting save for map: %v", err)
+		}
+		MapWindow(app, saveData).Show()
+	})
 	statusLabel := widget.NewLabelWithData(status)
+	budgetLabel := widget.NewLabelWithData(budgetStatus)
 
-	window.SetContent(container.NewVBox(title, settingsForm, startButton, stopButton, statusLabel))
-	return app, window
+	return container.NewVBox(title, profileRow, settingsForm, startButton, stopButton, mapButton, statusLabel, budgetLabel)
+}
+
+// ProfileSetup takes the savePath, a SaveData and a function to call when the active profile changes, and creates
+// a row to switch, save, delete and watch profiles, with the active profile's progress and the combined total.
+// Returns a Fyne Container for inclusion in a Fyne Window.
+func ProfileSetup(savePath string, saveData types.SaveData, onChange func()) *fyne.Container {
+	uiProfileName := widget.NewEntry()
+	uiProfileName.SetPlaceHolder(i18n.T("ui.profile_name"))
+	uiProfileName.SetText(saveData.ActiveProfile)
+
+	// The change is made to the settings as they are saved now, as progress and other saves may have changed them
+	// since the window was built. Restarting spotting reads the secrets, which mustn't freeze the window while the
+	// keyring waits to be unlocked
+	changeProfile := func(change func(types.Config) (types.Config, error)) {
+		go func() {
+			current, err := currentConfig(savePath)
+			if err != nil {
+				log.Printf("Error getting save: %v", err)
+				return
+			}
+			newConfig, err := change(current)
+			if err != nil {
+				log.Printf("Error changing profile: %v", err)
+				return
+			}
+			SaveConfig(savePath, newConfig)
+			if started {
+				stopUpdateLoop()
+				url, saveData := InitSaveData(savePath)
+				startUpdateLoop(url, saveData)
+			}
+			onChange()
+		}()
+	}
+
+	uiProfiles := widget.NewSelect(profiles.Names(saveData.Profiles), nil)
+	uiProfiles.PlaceHolder = i18n.T("ui.no_profile")
+	uiProfiles.SetSelected(saveData.ActiveProfile)
+	uiProfiles.OnChanged = func(name string) {
+		if name == saveData.ActiveProfile {
+			return
+		}
+		changeProfile(func(c types.Config) (types.Config, error) {
+			return profiles.Switch(c, name)
+		})
+	}
+
+	saveButton := widget.NewButton(i18n.T("ui.save_profile"), func() {
+		name := uiProfileName.Text
+		changeProfile(func(c types.Config) (types.Config, error) {
+			return profiles.Store(c, name)
+		})
+	})
+	deleteButton := widget.NewButton(i18n.T("ui.delete_profile"), func() {
+		changeProfile(func(c types.Config) (types.Config, error) {
+			return profiles.Delete(c, c.ActiveProfile), nil
+		})
+	})
+	if saveData.ActiveProfile == "" {
+		deleteButton.Disable()
+	}
+
+	var watched []string
+	for _, p := range saveData.Profiles {
+		if p.Watch {
+			watched = append(watched, p.Name)
+		}
+	}
+	uiWatch := widget.NewCheckGroup(profiles.Names(saveData.Profiles), nil)
+	uiWatch.Horizontal = true
+	uiWatch.SetSelected(watched)
+	uiWatch.OnChanged = func(selected []string) {
+		changeProfile(func(c types.Config) (types.Config, error) {
+			return profiles.SetWatched(c, selected), nil
+		})
+	}
+
+	progressText := i18n.T("ui.total_seen", saveData.SeenCount)
+	if i := profiles.Find(saveData.Profiles, saveData.ActiveProfile); i != -1 {
+		progressText = i18n.T("ui.profile_seen", saveData.Profiles[i].Name, saveData.Profiles[i].Progress.SeenCount, progressText)
+	}
+
+	return container.NewVBox(
+		container.NewGridWithColumns(2, uiProfiles, uiProfileName),
+		container.NewGridWithColumns(2, saveButton, deleteButton),
+		widget.NewForm(widget.NewFormItem(i18n.T("ui.also_watch"), uiWatch)),
+		widget.NewLabel(progressText),
+	)
 }
 
 // WindowSetup takes the Fyne App and icon (Fyne Resource) and creates the window with correct size and title
@@ -54,55 +184,640 @@ func WindowSetup(app fyne.App, icon fyne.Resource) fyne.Window {
 	return window
 }
 
-// FormSetup takes the savePath and a SaveData, and creates the settings form for the user
-// to update their config with save button. Returns a Fyne Container for inclusion in a Fyne Window.
-func FormSetup(savePath string, saveData types.SaveData) *fyne.Container {
+// FormSetup takes the savePath, a SaveData and a function to call once the settings are saved, and creates the
+// settings form for the user to update their config with save button. Returns a Fyne Container for inclusion in a Fyne Window.
+func FormSetup(savePath string, saveData types.SaveData, onSave func()) *fyne.Container {
 	uiLatitude := widget.NewEntry()
 	uiLatitude.SetText(fmt.Sprintf("%v", saveData.Position.Latitude))
+	uiLatitude.Validator = validate.Number(validate.Latitude)
 
 	uiLongitude := widget.NewEntry()
 	uiLongitude.SetText(fmt.Sprintf("%v", saveData.Position.Longitude))
+	uiLongitude.Validator = validate.Number(validate.Longitude)
 
-	uiSpotDistance := widget.NewEntry()
-	uiSpotDistance.SetText(strconv.Itoa(saveData.SpotDistanceKm))
+	uiElevation := widget.NewEntry()
+	uiElevation.SetText(fmt.Sprintf("%v", saveData.Position.ElevationM))
+	uiElevation.Validator = func(text string) error {
+		_, err := validate.ParseNumber(text)
+		return err
+	}
 
-	uiCheckFreq := widget.NewEntry()
-	uiCheckFreq.SetText(strconv.Itoa(saveData.CheckFreqSeconds))
+	uiFindPlace := placeSearch(saveData.Geocoding, func(p types.Place) {
+		uiLatitude.SetText(fmt.Sprintf("%v", p.Position.Latitude))
+		uiLongitude.SetText(fmt.Sprintf("%v", p.Position.Longitude))
+	})
+
+	uiGazetteer := widget.NewEntry()
+	uiGazetteer.SetPlaceHolder("cities15000.txt")
+	uiGazetteer.SetText(saveData.Geocoding.Gazetteer)
+
+	uiGeocodeUrl := widget.NewEntry()
+	uiGeocodeUrl.SetPlaceHolder("https://nominatim.openstreetmap.org/search")
+	uiGeocodeUrl.SetText(saveData.Geocoding.Url)
+
+	uiAltitudeUnit := widget.NewSelect(formatters.AltitudeUnits, nil)
+	uiAltitudeUnit.SetSelected(formatters.AltitudeUnit(saveData.Units.Altitude))
+
+	uiSpeedUnit := widget.NewSelect(formatters.SpeedUnits, nil)
+	uiSpeedUnit.SetSelected(formatters.SpeedUnit(saveData.Units.Speed))
+
+	uiDistanceUnit := widget.NewSelect(formatters.DistanceUnits, nil)
+	uiDistanceUnit.SetSelected(formatters.DistanceUnit(saveData.Units.Distance))
+
+	uiVerticalRateUnit := widget.NewSelect(formatters.VerticalRateUnits, nil)
+	uiVerticalRateUnit.SetSelected(formatters.VerticalRateUnit(saveData.Units.VerticalRate))
+
+	// The spot distance is saved in whole km, so it is checked as it will be saved
+	uiSpotDistance := newUnitEntry(i18n.T("form.spot_distance"), "", float64(saveData.SpotDistanceKm), uiDistanceUnit.Selected, func(km float64) error {
+		return validate.SpotDistanceKm(math.Round(km))
+	})
 
 	uiUsername := widget.NewEntry()
 	uiUsername.SetText(saveData.ApiAuth.Username)
 
+	uiClientId := widget.NewEntry()
+	uiClientId.SetText(saveData.ApiAuth.ClientId)
+
+	uiClientSecret := widget.NewPasswordEntry()
+	uiClientSecret.SetText(saveData.ApiAuth.ClientSecret)
+
+	// Accounts can check more often, so the minimum depends on the username or client id entered
+	uiCheckFreq := widget.NewEntry()
+	uiCheckFreq.SetText(strconv.Itoa(saveData.CheckFreqSeconds))
+	uiCheckFreq.Validator = func(text string) error {
+		return validate.Whole(validate.CheckFreqSeconds(types.ApiAuth{Username: uiUsername.Text, ClientId: uiClientId.Text}))(text)
+	}
+	uiUsername.OnChanged = func(string) { uiCheckFreq.Validate() }
+	uiClientId.OnChanged = func(string) { uiCheckFreq.Validate() }
+
 	uiPassword := widget.NewPasswordEntry()
 	uiPassword.SetText(saveData.ApiAuth.Password)
 
+	uiDailyCredits := widget.NewEntry()
+	uiDailyCredits.SetText(strconv.Itoa(saveData.Budget.DailyCredits))
+	uiDailyCredits.Validator = validate.Whole(validate.NotNegativeWhole)
+
+	adaptOptions := newOptions("budget_adapt.", budget.Adapts)
+	uiBudgetAdapt := widget.NewSelect(adaptOptions.labels, nil)
+	uiBudgetAdapt.SetSelected(adaptOptions.Label(budget.Interval))
+	if saveData.Budget.Adapt != "" {
+		uiBudgetAdapt.SetSelected(adaptOptions.Label(saveData.Budget.Adapt))
+	}
+
+	uiMaxStateAge := widget.NewEntry()
+	uiMaxStateAge.SetText(strconv.Itoa(saveData.States.MaxAgeSeconds))
+	uiMaxStateAge.Validator = validate.Whole(validate.NotNegativeWhole)
+
+	uiExtended := widget.NewCheck("", nil)
+	uiExtended.SetChecked(saveData.States.Extended)
+
+	uiWatchlist := widget.NewMultiLineEntry()
+	uiWatchlist.SetPlaceHolder("4007f5, 400a0b")
+	uiWatchlist.SetText(strings.Join(saveData.States.Icao24, ", "))
+	uiWatchlist.Validator = validate.Icao24
+
+	shapeOptions := newOptions("shape.", areas.Shapes)
+	uiShape := widget.NewSelect(shapeOptions.labels, nil)
+	uiShape.SetSelected(shapeOptions.Label(areas.Radius))
+	if saveData.Shape.Type != "" {
+		uiShape.SetSelected(shapeOptions.Label(saveData.Shape.Type))
+	}
+
+	uiShapePoints := widget.NewMultiLineEntry()
+	uiShapePoints.SetPlaceHolder("51.4650, -0.4340\n51.4650, -0.1000")
+	uiShapePoints.SetText(areas.FormatPoints(saveData.Shape.Points))
+	uiShapePoints.Validator = validate.Points
+
+	uiCorridorWidth := newUnitEntry(i18n.T("form.corridor_width"), i18n.T("form.corridor_width_hint"), saveData.Shape.CorridorWidthKm, uiDistanceUnit.Selected, validate.NotNegative)
+	uiMinAltitude := newUnitEntry(i18n.T("form.minimum_altitude"), "", saveData.Volume.MinAltitudeM, uiAltitudeUnit.Selected, validate.NotNegative)
+	uiMaxAltitude := newUnitEntry(i18n.T("form.maximum_altitude"), i18n.T("form.maximum_altitude_hint"), saveData.Volume.MaxAltitudeM, uiAltitudeUnit.Selected, func(m float64) error {
+		return validate.MaxAltitude(uiMinAltitude.Value())(m)
+	})
+	uiMinAltitude.entry.OnChanged = func(string) { uiMaxAltitude.entry.Validate() }
+
+	altitudeSourceOptions := newOptions("altitude_source.", areas.AltitudeSources)
+	uiAltitudeSource := widget.NewSelect(altitudeSourceOptions.labels, nil)
+	uiAltitudeSource.SetSelected(altitudeSourceOptions.Label(areas.BaroAltitude))
+	if saveData.Volume.AltitudeSource != "" {
+		uiAltitudeSource.SetSelected(altitudeSourceOptions.Label(saveData.Volume.AltitudeSource))
+	}
+
+	verticalRateOptions := newOptions("vertical_rate.", areas.VerticalRates)
+	uiVerticalRate := widget.NewSelect(verticalRateOptions.labels, nil)
+	uiVerticalRate.SetSelected(verticalRateOptions.Label(areas.AnyRate))
+	if saveData.Volume.VerticalRate != "" {
+		uiVerticalRate.SetSelected(verticalRateOptions.Label(saveData.Volume.VerticalRate))
+	}
+
+	uiMinVerticalRate := newUnitEntry(i18n.T("form.vertical_rate_threshold"), i18n.T("form.vertical_rate_threshold_hint"), saveData.Volume.MinVerticalRateMs, uiVerticalRateUnit.Selected, validate.NotNegative)
+	uiPassDistance := newUnitEntry(i18n.T("form.pass_alert_distance"), i18n.T("form.pass_alert_distance_hint"), saveData.PassAlert.WithinKm, uiDistanceUnit.Selected, validate.NotNegative)
+
+	uiPassMinutes := widget.NewEntry()
+	uiPassMinutes.SetText(strconv.Itoa(saveData.PassAlert.Minutes))
+	uiPassMinutes.Validator = validate.Whole(validate.NotNegativeWhole)
+
+	sunRuleOptions := newOptions("sun_rule.", sun.Rules)
+	uiSunRule := widget.NewSelect(sunRuleOptions.labels, nil)
+	uiSunRule.SetSelected(sunRuleOptions.Label(sun.Always))
+	if saveData.SunRule != "" {
+		uiSunRule.SetSelected(sunRuleOptions.Label(saveData.SunRule))
+	}
+
+	uiQuietStart := widget.NewEntry()
+	uiQuietStart.SetPlaceHolder("22:00")
+	uiQuietStart.SetText(saveData.Schedule.QuietStart)
+	uiQuietStart.Validator = validate.Clock
+
+	uiQuietEnd := widget.NewEntry()
+	uiQuietEnd.SetPlaceHolder("07:00")
+	uiQuietEnd.SetText(saveData.Schedule.QuietEnd)
+	uiQuietEnd.Validator = validate.Clock
+
+	dayOptions := newOptions("weekday.", schedule.Weekdays)
+	uiDays := widget.NewCheckGroup(dayOptions.labels, nil)
+	uiDays.Horizontal = true
+	uiDays.SetSelected(dayOptions.Labels(saveData.Schedule.Days))
+	if len(saveData.Schedule.Days) == 0 {
+		uiDays.SetSelected(dayOptions.labels)
+	}
+
+	batchModeOptions := newOptions("batch_mode.", batch.Modes)
+	uiBatchMode := widget.NewSelect(batchModeOptions.labels, nil)
+	uiBatchMode.SetSelected(batchModeOptions.Label(batch.Each))
+	if saveData.Batching.Mode != "" {
+		uiBatchMode.SetSelected(batchModeOptions.Label(saveData.Batching.Mode))
+	}
+
+	uiDigestMinutes := widget.NewEntry()
+	uiDigestMinutes.SetText(strconv.Itoa(saveData.Batching.DigestMinutes))
+	uiDigestMinutes.Validator = validate.Whole(validate.NotNegativeWhole)
+
+	uiMaxPerMinute := widget.NewEntry()
+	uiMaxPerMinute.SetText(strconv.Itoa(saveData.Batching.MaxPerMinute))
+	uiMaxPerMinute.Validator = validate.Whole(validate.NotNegativeWhole)
+
+	uiTitleTemplate := widget.NewEntry()
+	uiTitleTemplate.SetPlaceHolder(templates.DefaultTitle)
+	uiTitleTemplate.SetText(saveData.Templates.Title)
+	uiTitleTemplate.Validator = validate.Template
+
+	uiMessageTemplate := widget.NewMultiLineEntry()
+	uiMessageTemplate.SetPlaceHolder(templates.DefaultMessage)
+	uiMessageTemplate.SetText(saveData.Templates.Message)
+	uiMessageTemplate.Validator = validate.Template
+
+	uiTemplatePreview := widget.NewLabel("")
+	uiTemplatePreview.Wrapping = fyne.TextWrapWord
+	selectedUnits := func() types.Units {
+		return types.Units{Altitude: uiAltitudeUnit.Selected, Speed: uiSpeedUnit.Selected, Distance: uiDistanceUnit.Selected, VerticalRate: uiVerticalRateUnit.Selected}
+	}
+	previewTemplates := func(string) {
+		sample := templates.Sample()
+		sample.Units = selectedUnits()
+		sample.PlaneInfo = formatters.ApplyUnits(sample.PlaneInfo, sample.Units)
+		title, message, err := templates.Render(types.MessageTemplates{Title: uiTitleTemplate.Text, Message: uiMessageTemplate.Text}, sample)
+		if err != nil {
+			uiTemplatePreview.SetText(i18n.T("ui.template_error", err))
+			return
+		}
+		uiTemplatePreview.SetText(title + "\n" + message)
+	}
+	uiTitleTemplate.OnChanged = previewTemplates
+	uiMessageTemplate.OnChanged = previewTemplates
+	previewTemplates("")
+
+	uiBasemapDir := widget.NewEntry()
+	uiBasemapDir.SetPlaceHolder(defaultBasemapDir)
+	uiBasemapDir.SetText(saveData.Basemap.Directory)
+
+	projectionOptions := newOptions("projection.", []string{basemap.Equirectangular, basemap.WebMercator})
+	uiProjection := widget.NewSelect(projectionOptions.labels, nil)
+	uiProjection.SetSelected(projectionOptions.Label(saveData.Basemap.Projection))
+
+	// The language is chosen by name, with the first option detecting it from the environment
+	languageOptions := []string{i18n.T("form.language_auto")}
+	for _, language := range i18n.Languages {
+		languageOptions = append(languageOptions, i18n.Names[language])
+	}
+	uiLanguage := widget.NewSelect(languageOptions, nil)
+	uiLanguage.SetSelected(languageOptions[0])
+	if name, ok := i18n.Names[saveData.Language]; ok {
+		uiLanguage.SetSelected(name)
+	}
+
 	settingsForm := &widget.Form{
 		Items: []*widget.FormItem{
-			{Text: "Latitude", HintText: "Decimal degrees", Widget: uiLatitude},
-			{Text: "Longitude", HintText: "Decimal degrees", Widget: uiLongitude},
-			{Text: "OpenSky username", Widget: uiUsername},
-			{Text: "OpenSky password", Widget: uiPassword},
-			{Text: "Spot distance (km)", Widget: uiSpotDistance},
-			{Text: "Check frequency (seconds)", Widget: uiCheckFreq},
+			{Text: i18n.T("form.find_place"), HintText: i18n.T("form.find_place_hint"), Widget: uiFindPlace},
+			{Text: i18n.T("form.gazetteer"), HintText: i18n.T("form.gazetteer_hint"), Widget: uiGazetteer},
+			{Text: i18n.T("form.geocoder_url"), HintText: i18n.T("form.geocoder_url_hint"), Widget: uiGeocodeUrl},
+			{Text: i18n.T("form.latitude"), HintText: i18n.T("form.latitude_hint"), Widget: uiLatitude},
+			{Text: i18n.T("form.longitude"), HintText: i18n.T("form.longitude_hint"), Widget: uiLongitude},
+			{Text: i18n.T("form.elevation"), HintText: i18n.T("form.elevation_hint"), Widget: uiElevation},
+			{Text: i18n.T("form.opensky_username"), Widget: uiUsername},
+			{Text: i18n.T("form.opensky_password"), Widget: uiPassword},
+			{Text: i18n.T("form.opensky_client_id"), HintText: i18n.T("form.opensky_client_id_hint"), Widget: uiClientId},
+			{Text: i18n.T("form.opensky_client_secret"), Widget: uiClientSecret},
+			uiSpotDistance.item,
+			{Text: i18n.T("form.check_frequency"), Widget: uiCheckFreq},
+			{Text: i18n.T("form.daily_credits"), HintText: i18n.T("form.daily_credits_hint"), Widget: uiDailyCredits},
+			{Text: i18n.T("form.budget_adapt"), HintText: i18n.T("form.budget_adapt_hint"), Widget: uiBudgetAdapt},
+			{Text: i18n.T("form.max_state_age"), HintText: i18n.T("form.max_state_age_hint"), Widget: uiMaxStateAge},
+			{Text: i18n.T("form.categories"), HintText: i18n.T("form.categories_hint"), Widget: uiExtended},
+			{Text: i18n.T("form.watchlist"), HintText: i18n.T("form.watchlist_hint"), Widget: uiWatchlist},
+			{Text: i18n.T("form.spot_area_shape"), Widget: uiShape},
+			{Text: i18n.T("form.shape_points"), HintText: i18n.T("form.shape_points_hint"), Widget: uiShapePoints},
+			uiCorridorWidth.item,
+			uiMinAltitude.item,
+			uiMaxAltitude.item,
+			{Text: i18n.T("form.altitude_source"), Widget: uiAltitudeSource},
+			{Text: i18n.T("form.vertical_rate"), Widget: uiVerticalRate},
+			uiMinVerticalRate.item,
+			uiPassDistance.item,
+			{Text: i18n.T("form.pass_alert_time"), HintText: i18n.T("form.pass_alert_time_hint"), Widget: uiPassMinutes},
+			{Text: i18n.T("form.notify"), HintText: i18n.T("form.notify_hint"), Widget: uiSunRule},
+			{Text: i18n.T("form.quiet_from"), HintText: i18n.T("form.quiet_from_hint"), Widget: uiQuietStart},
+			{Text: i18n.T("form.quiet_until"), HintText: i18n.T("form.quiet_until_hint"), Widget: uiQuietEnd},
+			{Text: i18n.T("form.notify_on"), Widget: uiDays},
+			{Text: i18n.T("form.notify_new_planes"), HintText: i18n.T("form.notify_new_planes_hint"), Widget: uiBatchMode},
+			{Text: i18n.T("form.digest_every"), Widget: uiDigestMinutes},
+			{Text: i18n.T("form.max_notifications_a_minute"), HintText: i18n.T("form.max_notifications_a_minute_hint"), Widget: uiMaxPerMinute},
+			{Text: i18n.T("form.notification_title"), HintText: i18n.T("form.notification_title_hint"), Widget: uiTitleTemplate},
+			{Text: i18n.T("form.notification_message"), HintText: i18n.T("form.notification_message_hint"), Widget: uiMessageTemplate},
+			{Text: i18n.T("form.preview"), Widget: uiTemplatePreview},
+			{Text: i18n.T("form.altitude_units"), Widget: uiAltitudeUnit},
+			{Text: i18n.T("form.speed_units"), Widget: uiSpeedUnit},
+			{Text: i18n.T("form.distance_units"), Widget: uiDistanceUnit},
+			{Text: i18n.T("form.vertical_rate_units"), Widget: uiVerticalRateUnit},
+			{Text: i18n.T("form.basemap_folder"), HintText: i18n.T("form.basemap_folder_hint"), Widget: uiBasemapDir},
+			{Text: i18n.T("form.map_projection"), Widget: uiProjection},
+			{Text: i18n.T("form.language"), HintText: i18n.T("form.language_hint"), Widget: uiLanguage},
 		},
-		SubmitText: "Save",
+		SubmitText: i18n.T("ui.save"),
 		OnSubmit: func() {
-			var newConfig types.Config
-			newConfig.Position.Latitude, _ = strconv.ParseFloat(uiLatitude.Text, 64)
-			newConfig.Position.Longitude, _ = strconv.ParseFloat(uiLongitude.Text, 64)
+			// Progress and other saves may have changed the save since the form was built, so only the settings in
+			// the form are replaced
+			newConfig, err := currentConfig(savePath)
+			if err != nil {
+				log.Printf("Error getting save: %v", err)
+				status.Set(i18n.T("status.save_error", err))
+				return
+			}
+			newConfig.Position.Latitude, _ = validate.ParseNumber(uiLatitude.Text)
+			newConfig.Position.Longitude, _ = validate.ParseNumber(uiLongitude.Text)
+			newConfig.Position.ElevationM, _ = validate.ParseNumber(uiElevation.Text)
+			newConfig.Geocoding.Gazetteer = uiGazetteer.Text
+			newConfig.Geocoding.Url = uiGeocodeUrl.Text
 			newConfig.ApiAuth.Username = uiUsername.Text
 			newConfig.ApiAuth.Password = uiPassword.Text
-			newConfig.SpotDistanceKm, _ = strconv.Atoi(uiSpotDistance.Text)
-			newConfig.CheckFreqSeconds, _ = strconv.Atoi(uiCheckFreq.Text)
-			SaveConfig(savePath, newConfig)
-			if started {
-				stopUpdateLoop()
+			newConfig.ApiAuth.ClientId = uiClientId.Text
+			newConfig.ApiAuth.ClientSecret = uiClientSecret.Text
+			newConfig.SpotDistanceKm = int(math.Round(uiSpotDistance.Value()))
+			newConfig.CheckFreqSeconds, _ = validate.ParseWhole(uiCheckFreq.Text)
+			newConfig.Budget.DailyCredits, _ = validate.ParseWhole(uiDailyCredits.Text)
+			newConfig.Budget.Adapt = adaptOptions.Value(uiBudgetAdapt.Selected)
+			newConfig.States.MaxAgeSeconds, _ = validate.ParseWhole(uiMaxStateAge.Text)
+			newConfig.States.Extended = uiExtended.Checked
+			newConfig.States.Icao24, _ = states.ParseIcao24(uiWatchlist.Text)
+			newConfig.Shape.Type = shapeOptions.Value(uiShape.Selected)
+			newConfig.Shape.Points, _ = areas.ParsePoints(uiShapePoints.Text)
+			newConfig.Shape.CorridorWidthKm = uiCorridorWidth.Value()
+			newConfig.Volume.MinAltitudeM = uiMinAltitude.Value()
+			newConfig.Volume.MaxAltitudeM = uiMaxAltitude.Value()
+			newConfig.Volume.AltitudeSource = altitudeSourceOptions.Value(uiAltitudeSource.Selected)
+			newConfig.Volume.VerticalRate = verticalRateOptions.Value(uiVerticalRate.Selected)
+			newConfig.Volume.MinVerticalRateMs = uiMinVerticalRate.Value()
+			newConfig.PassAlert.WithinKm = uiPassDistance.Value()
+			newConfig.PassAlert.Minutes, _ = validate.ParseWhole(uiPassMinutes.Text)
+			newConfig.SunRule = sunRuleOptions.Value(uiSunRule.Selected)
+			newConfig.Schedule.QuietStart = uiQuietStart.Text
+			newConfig.Schedule.QuietEnd = uiQuietEnd.Text
+			newConfig.Schedule.Days = dayOptions.Values(uiDays.Selected)
+			if len(uiDays.Selected) == len(schedule.Weekdays) {
+				newConfig.Schedule.Days = nil
 			}
-			url, saveData := InitSaveData(savePath)
-			startUpdateLoop(url, saveData)
-
+			newConfig.Batching.Mode = batchModeOptions.Value(uiBatchMode.Selected)
+			newConfig.Batching.DigestMinutes, _ = validate.ParseWhole(uiDigestMinutes.Text)
+			newConfig.Batching.MaxPerMinute, _ = validate.ParseWhole(uiMaxPerMinute.Text)
+			newConfig.Templates.Title = uiTitleTemplate.Text
+			newConfig.Templates.Message = uiMessageTemplate.Text
+			newConfig.Units = selectedUnits()
+			newConfig.Basemap.Directory = uiBasemapDir.Text
+			newConfig.Basemap.Projection = projectionOptions.Value(uiProjection.Selected)
+			newConfig.Language = ""
+			for _, language := range i18n.Languages {
+				if i18n.Names[language] == uiLanguage.Selected {
+					newConfig.Language = language
+				}
+			}
+			if newConfig.ActiveProfile != "" {
+				newConfig, _ = profiles.Store(newConfig, newConfig.ActiveProfile)
+			}
+			// The keyring may ask to be unlocked, which mustn't freeze the window while it waits
+			go func() {
+				if newConfig.Secrets.Backend != "" {
+					if err := saveSecrets(newConfig); err != nil {
+						log.Printf("Error saving secrets: %v", err)
+						status.Set(i18n.T("status.secrets_error", err))
+						return
+					}
+				}
+				SaveConfig(savePath, newConfig)
+				if started {
+					stopUpdateLoop()
+				}
+				url, saveData := InitSaveData(savePath)
+				startUpdateLoop(url, saveData)
+				onSave()
+			}()
 		},
 	}
 
+	// Changing a unit converts the values already entered, so they keep meaning the same distance or altitude
+	onUnitChanged := func(entries ...*unitEntry) func(string) {
+		return func(unit string) {
+			for _, e := range entries {
+				e.SetUnit(unit)
+			}
+			settingsForm.Refresh()
+			previewTemplates("")
+		}
+	}
+	uiAltitudeUnit.OnChanged = onUnitChanged(uiMinAltitude, uiMaxAltitude)
+	uiSpeedUnit.OnChanged = onUnitChanged()
+	uiDistanceUnit.OnChanged = onUnitChanged(uiSpotDistance, uiCorridorWidth, uiPassDistance)
+	uiVerticalRateUnit.OnChanged = onUnitChanged(uiMinVerticalRate)
+
 	c := container.NewVBox(settingsForm)
 	return c
 }
+
+// currentConfig takes the savePath and returns the Config saved there now, which checks, the background save and
+// the command line may have changed since the window was built
+func currentConfig(savePath string) (types.Config, error) {
+	saveData, err := GetSave(savePath)
+	if err != nil {
+		return types.Config{}, err
+	}
+
+	return saveData.Config, nil
+}
+
+// saveSecrets takes a Config and saves its password and client secret in its secrets store
+// The keyring can wait minutes for the user to unlock it, so it mustn't be called on the UI goroutine
+func saveSecrets(c types.Config) error {
+	store, err := secrets.Open(c.Secrets, passphrase())
+	if err != nil {
+		return err
+	}
+	defer secrets.Close(store)
+	_, err = secrets.Save(store, c.ApiAuth)
+	return err
+}
+
+// SecretsPrompt takes the Fyne App and Window, the savePath and Save Data, and asks once whether to move a password
+// or client secret kept in the save into the keyring or an encrypted file. If they are in an encrypted file and
+// there is no passphrase in the environment, it asks for the passphrase instead, then reloads the window's content
+func SecretsPrompt(app fyne.App, window fyne.Window, savePath string, saveData types.SaveData) {
+	// Run off the UI goroutine, as loading the save reads the secrets and the keyring may ask to be unlocked
+	reload := func() {
+		url, saveData := InitSaveData(savePath)
+		window.SetContent(ContentSetup(app, window, url, savePath, saveData))
+	}
+
+	switch {
+	case saveData.Secrets.Backend == secrets.File && passphrase() == "":
+		uiPassphrase := widget.NewPasswordEntry()
+		uiPassphrase.Validator = func(text string) error {
+			if text == "" {
+				return errors.New(i18n.T("validate.passphrase"))
+			}
+			return nil
+		}
+		dialog.ShowForm(i18n.T("ui.unlock_secrets"), i18n.T("ui.unlock"), i18n.T("ui.cancel"), []*widget.FormItem{
+			{Text: i18n.T("form.passphrase"), HintText: i18n.T("form.passphrase_hint", secrets.PassphraseVariable), Widget: uiPassphrase},
+		}, func(ok bool) {
+			if !ok {
+				return
+			}
+			entered := uiPassphrase.Text
+			go func() {
+				store, err := secrets.Open(saveData.Secrets, entered)
+				if err == nil {
+					_, err = secrets.Load(store, saveData.ApiAuth)
+					secrets.Close(store)
+				}
+				if err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				secretsPassphrase = entered
+				reload()
+			}()
+		}, window)
+
+	case saveData.Secrets.Backend == "" && !saveData.Secrets.Declined && (saveData.ApiAuth.Password != "" || saveData.ApiAuth.ClientSecret != ""):
+		backendOptions := newOptions("secrets_backend.", []string{secrets.Keyring, secrets.File})
+		uiBackend := widget.NewSelect(backendOptions.labels, nil)
+		uiBackend.SetSelected(backendOptions.Label(secrets.Keyring))
+		uiPassphrase := widget.NewPasswordEntry()
+		uiPassphrase.Validator = func(text string) error {
+			if backendOptions.Value(uiBackend.Selected) == secrets.File && text == "" {
+				return errors.New(i18n.T("validate.passphrase"))
+			}
+			return nil
+		}
+		uiBackend.OnChanged = func(string) { uiPassphrase.Validate() }
+
+		dialog.ShowForm(i18n.T("ui.move_secrets"), i18n.T("ui.move"), i18n.T("ui.keep_in_save"), []*widget.FormItem{
+			{Widget: widget.NewLabel(i18n.T("ui.move_secrets_message"))},
+			{Text: i18n.T("form.secrets_backend"), Widget: uiBackend},
+			{Text: i18n.T("form.passphrase"), HintText: i18n.T("form.passphrase_hint", secrets.PassphraseVariable), Widget: uiPassphrase},
+		}, func(move bool) {
+			newConfig, err := currentConfig(savePath)
+			if err != nil {
+				log.Printf("Error getting save: %v", err)
+				return
+			}
+			if !move {
+				newConfig.Secrets.Declined = true
+				SaveConfig(savePath, newConfig)
+				return
+			}
+
+			newConfig.Secrets = types.Secrets{Backend: backendOptions.Value(uiBackend.Selected)}
+			if newConfig.Secrets.Backend == secrets.File {
+				secretsPassphrase = uiPassphrase.Text
+			}
+			go func() {
+				if err := saveSecrets(newConfig); err != nil {
+					log.Printf("Error moving secrets: %v", err)
+					dialog.ShowError(err, window)
+					return
+				}
+				SaveConfig(savePath, newConfig)
+				reload()
+			}()
+		}, window)
+	}
+}
+
+// placeSearch takes the Geocoding settings and a function to call with the place picked, and creates a search box
+// for towns, postcodes and airports with a list of the places found. Returns a Fyne Container for the settings form
+func placeSearch(settings types.Geocoding, onPick func(types.Place)) *fyne.Container {
+	geocoder, err := geocode.New(settings)
+	if err != nil {
+		log.Printf("Error setting up place search, using the built in gazetteer: %v", err)
+		geocoder = geocode.Default()
+	}
+
+	var found []types.Place
+	uiPlaces := widget.NewSelect(nil, func(selected string) {
+		for _, p := range found {
+			if geocode.Describe(p) == selected {
+				onPick(p)
+			}
+		}
+	})
+	uiPlaces.PlaceHolder = i18n.T("ui.pick_place")
+	uiPlaces.Hide()
+
+	uiQuery := widget.NewEntry()
+	search := func() {
+		places, err := geocoder.Search(uiQuery.Text, geocode.DefaultLimit)
+		found = places
+		var options []string
+		for _, p := range places {
+			options = append(options, geocode.Describe(p))
+		}
+
+		uiPlaces.ClearSelected()
+		uiPlaces.Options = options
+		switch {
+		case err != nil:
+			log.Printf("Error searching for %q: %v", uiQuery.Text, err)
+			uiPlaces.PlaceHolder = i18n.T("ui.place_error")
+		case len(places) == 0:
+			uiPlaces.PlaceHolder = i18n.T("ui.no_places")
+		default:
+			uiPlaces.PlaceHolder = i18n.T("ui.pick_place")
+		}
+		uiPlaces.Show()
+		uiPlaces.Refresh()
+
+		// One match is picked straight away
+		if len(places) == 1 {
+			uiPlaces.SetSelected(options[0])
+		}
+	}
+	uiQuery.OnSubmitted = func(string) { go search() }
+	searchButton := widget.NewButton(i18n.T("ui.search"), func() { go search() })
+
+	return container.NewVBox(container.NewBorder(nil, nil, nil, searchButton, uiQuery), uiPlaces)
+}
+
+// unitEntry is a settings entry for a value stored in metres, m/s or km, but shown in the unit the user chose
+type unitEntry struct {
+	entry *widget.Entry
+	item  *widget.FormItem
+	label string
+	unit  string
+	value float64
+	shown string
+}
+
+// newUnitEntry takes a label, hint text, the stored value, the unit to show it in and a check for the value
+// in metres, m/s or km, and returns a unitEntry whose form item is labelled with the unit
+func newUnitEntry(label, hint string, value float64, unit string, check func(float64) error) *unitEntry {
+	e := &unitEntry{entry: widget.NewEntry(), label: label, unit: unit}
+	e.item = &widget.FormItem{HintText: hint, Widget: e.entry}
+	e.entry.Validator = func(text string) error {
+		value, err := validate.ParseNumber(text)
+		if err != nil {
+			return err
+		}
+		return check(formatters.FromUnit(value, e.unit))
+	}
+	e.show(value)
+	return e
+}
+
+// Value returns the entered value converted back to metres, m/s or km
+// If the text hasn't been changed the value it shows is returned as it was, so rounding it for display
+// doesn't change it every time the settings are saved
+func (e *unitEntry) Value() float64 {
+	if e.entry.Text == e.shown {
+		return e.value
+	}
+
+	value, _ := validate.ParseNumber(e.entry.Text)
+	return formatters.FromUnit(value, e.unit)
+}
+
+// SetUnit takes a unit and shows the entered value in it instead
+func (e *unitEntry) SetUnit(unit string) {
+	value := e.Value()
+	e.unit = unit
+	e.show(value)
+}
+
+// show takes a value in metres, m/s or km and shows it in the entry's unit, to 2 decimal places at most
+func (e *unitEntry) show(value float64) {
+	e.value = value
+	e.shown = strconv.FormatFloat(math.Round(formatters.ToUnit(value, e.unit)*100)/100, 'f', -1, 64)
+	e.entry.SetText(e.shown)
+	e.item.Text = fmt.Sprintf("%v (%v)", e.label, e.unit)
+}
+
+// options are the stored values of a setting's choices, and their labels in the user's language
+type options struct {
+	values []string
+	labels []string
+}
+
+// newOptions takes the catalogue key prefix of a setting's choices and their stored values, and returns
+// their options. Each label is looked up under the prefix and the value in lower case with spaces as underscores
+func newOptions(prefix string, values []string) options {
+	o := options{values: values}
+	for _, value := range values {
+		o.labels = append(o.labels, i18n.T(prefix+strings.ReplaceAll(strings.ToLower(value), " ", "_")))
+	}
+	return o
+}
+
+// Label takes a stored value and returns its label, or the value itself if it isn't one of the options
+func (o options) Label(value string) string {
+	for i, v := range o.values {
+		if v == value {
+			return o.labels[i]
+		}
+	}
+	return value
+}
+
+// Value takes a label and returns its stored value, or the label itself if it isn't one of the options
+func (o options) Value(label string) string {
+	for i, l := range o.labels {
+		if l == label {
+			return o.values[i]
+		}
+	}
+	return label
+}
+
+// Labels takes stored values and returns their labels
+func (o options) Labels(values []string) []string {
+	var labels []string
+	for _, value := range values {
+		labels = append(labels, o.Label(value))
+	}
+	return labels
+}
+
+// Values takes labels and returns their stored values
+func (o options) Values(labels []string) []string {
+	var values []string
+	for _, label := range labels {
+		values = append(values, o.Value(label))
+	}
+	return values
+}
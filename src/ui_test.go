@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"planespotter/helpers/formatters"
+	"planespotter/helpers/i18n"
+	"planespotter/helpers/schedule"
+	"planespotter/helpers/sun"
+	"planespotter/helpers/types"
+	"planespotter/helpers/validate"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitEntry(t *testing.T) {
+	// 2 km shows as 1.08 nm, which would save as 2.0002 km if converted back
+	e := newUnitEntry("Corridor width", "", 2, formatters.NauticalMiles, validate.NotNegative)
+	assert.Equal(t, "1.08", e.entry.Text)
+	assert.Equal(t, 2.0, e.Value())
+	e.SetUnit(formatters.Miles)
+	e.SetUnit(formatters.NauticalMiles)
+	assert.Equal(t, 2.0, e.Value(), "Switching units back and forth doesn't drift")
+
+	e.entry.SetText("1")
+	assert.InDelta(t, 1.852, e.Value(), 0.0001)
+}
+
+func TestOptions(t *testing.T) {
+	i18n.Set(i18n.French)
+	defer i18n.Set(i18n.English)
+
+	// Labels are shown in the user's language, and saved as the values they stand for
+	o := newOptions("sun_rule.", sun.Rules)
+	assert.Equal(t, []string{"toujours", "de jour seulement", "de nuit seulement"}, o.labels)
+	assert.Equal(t, "de jour seulement", o.Label(sun.DaylightOnly))
+	assert.Equal(t, sun.DaylightOnly, o.Value("de jour seulement"))
+	assert.Equal(t, "", o.Label(""))
+
+	days := newOptions("weekday.", schedule.Weekdays)
+	assert.Equal(t, []string{"lun.", "dim."}, days.Labels([]string{"Mon", "Sun"}))
+	assert.Equal(t, []string{"Mon", "Sun"}, days.Values([]string{"lun.", "dim."}))
+}
+
+func TestCurrentConfig(t *testing.T) {
+	assert.NoError(t, CreateSaveIfNotExists(testSavePath))
+	defer os.Remove(testSavePath)
+
+	// Progress saved after the window was built is kept by changes made from it
+	SaveConfig(testSavePath, types.Config{ActiveProfile: "Home", Profiles: []types.Profile{{Name: "Home"}}})
+	SaveProgress(testSavePath, types.PlaneInfo{Callsign: "BAW12"})
+
+	c, err := currentConfig(testSavePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"BAW12"}, c.Profiles[0].Progress.Callsigns)
+
+	_, err = currentConfig("missing.json")
+	assert.Error(t, err)
+}